@@ -4,27 +4,23 @@ import (
 	"bytes"
 	"container/list"
 	"context"
-	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
+	"regexp"
 	"runtime"
+	"runtime/metrics"
 	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"sync"
 	"time"
 
 	ppf "github.com/sudachen/benchmark/ppftool"
-	"github.com/sudachen/misc"
 )
 
-var flagNoGC = flag.Bool("nogc", false, "disable GC on benchmark")
-var flagPprof = flag.Bool("pprof", false, "profile benchmarks")
-var flagMprof = flag.Bool("mprof", false, "profile memory allocations")
-var flagCpuProf = flag.String("cpuprof", misc.NulStr, "where to store cpuprofile")
-var flagMemProf = flag.String("memprof", misc.NulStr, "where to store memprofile")
-var flagCallGrapth = flag.Int("callgraph", 0, "count of nodes to write PNG callgraph")
-var flagNodeCount = flag.Int("nodecount", 20, "count of nodes to gather top calls")
-var flagResult = flag.String("result", misc.NulStr, "file name to write the benchmarking result")
-
 type messageKind byte
 
 const (
@@ -32,6 +28,7 @@ const (
 	MsgInfo
 	MsgDebug
 	MsgOpt
+	MsgSkip
 )
 
 func (mk messageKind) String() string {
@@ -44,6 +41,8 @@ func (mk messageKind) String() string {
 		return "MsgDebug"
 	case MsgOpt:
 		return "MsgOpt"
+	case MsgSkip:
+		return "MsgSkip"
 	}
 	return ""
 }
@@ -55,24 +54,74 @@ type Message struct {
 
 type T struct {
 	enableGC, isStarted, stopProfiler bool
-
-	processor          func(t *T, finished *T) *T
-	startedAt, runOn   time.Time
-	chActive, chPaused time.Duration
+	samples, isPaused                 bool
+	failed, skipped, filtered         bool
+
+	processor                            func(t *T, finished *T) *T
+	startedAt, runOn, markedAt, pausedAt time.Time
+	chActive, chPaused, markPaused       time.Duration
+	memStart, memNet, chMem              memCounter
+	rusageStart, rusageNet, chRusage     Rusage
+	hasRusage, chHasRusage               bool
+	rtStart                              []metrics.Sample
+	rtNet, chRuntime                     map[string]*RuntimeMetric
+	heapBase                             []byte
+	leaked                               map[string]bool
+	region                               *trace.Region
+	chProcessed                          int64
+
+	before, after, beforeEach, afterEach []func(*T) error
+	cleanups                             []func()
+
+	path   []string
+	filter []*regexp.Regexp
+
+	mu              sync.Mutex
+	parent, running *T
+	bench           *Benchmark
+
+	loopN, parallelism, laps int
+	ctx                      context.Context
+	cfg                      *Config
 
 	Err   error
+	Stack string
 	Label string
 	Count int
+	Procs int
+
+	Timeout  time.Duration
+	TimedOut bool
 
-	Active, Total time.Duration
+	Active, Total, Paused time.Duration
 
 	Children, Messages *list.List
-	Heap               *ppf.Report
+	Heap, HeapInuse    *ppf.Report
+	Cpu                *ppf.Report
+	Mem                *MemStats
+	Rusage             *Rusage
+	Runtime            map[string]*RuntimeMetric
+	Trace              *TraceSummary
+
+	Samples []time.Duration
+	Stats   *Stats
+
+	Bytes, Processed int64
+	Metrics          map[string]float64
+
+	Params    map[string]interface{}
+	Artifacts map[string][]byte
 }
 
 type Benchmark struct {
 	*T
-	Pprof *list.List
+	Pprof     *list.List
+	Affinity  []int
+	Metadata  *Metadata
+	TraceFile string
+
+	atAbort  []func()
+	aborting sync.Once
 }
 
 func New(label string) *T {
@@ -84,53 +133,77 @@ func New(label string) *T {
 	return t
 }
 
-var heapbf bytes.Buffer
-
-/*func (t *T) profileHeap() {
-	if *flagMprof {
-		runtime.GC()
-		pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("_", "heap")))
-		heapbf.Reset()
-		pprof.WriteHeapProfile(&heapbf)
-
-		opt := &ppf.Options{
-			Unit:     ppf.Second,
-			Count:    *flagNodeCount,
-			TagHide:  []string{"_:"},
-			Hide:     []string{"google/pprof\\.:"},
-		}
-
-		if rpt, err := ppf.Top(heapbf.Bytes(), opt); err == nil {
-			rpt.Label = "heap"
-			t.Heap = rpt
-		}
-		pprof.SetGoroutineLabels(context.Background())
-	}
-}*/
-
 func (t *T) run(f func(*T) error) (err error) {
 	if t.startedAt != (time.Time{}) {
 		panic("start is allowed only in leaf tasks")
 	} else {
-		pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("_", "prepare")))
+		t.setPrepareLabels()
+	}
+
+	if t.ctx == nil {
+		t.ctx = context.Background()
 	}
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		t.ctx, cancel = context.WithTimeout(t.ctx, t.Timeout)
+		defer cancel()
+	}
+
+	stopWatchdog := t.startWatchdog()
+	defer stopWatchdog()
 
 	t.runOn = time.Now()
-	t.Err = f(t)
-	t.Total = time.Since(t.runOn)
+	t.runHooks("BeforeEach", t.before)
+	if !t.Failed() && !t.skipped {
+		t.Err = t.call(f)
+	}
+
+	t.checkContext()
 
 	if t.Children.Len() != 0 {
 		t.Active = t.chActive
+		t.Paused = t.chPaused
+		t.Mem = t.chMem.stats(0)
+		if t.chHasRusage {
+			r := t.chRusage
+			t.Rusage = &r
+		}
+		t.Runtime = t.chRuntime
+		t.Processed = t.chProcessed
 	} else {
 		if t.isStarted {
-			t.Active = time.Since(t.startedAt)
+			now := time.Now()
+			t.lap(now)
+			if t.isPaused {
+				t.Paused += now.Sub(t.pausedAt)
+				t.isPaused = false
+			} else {
+				t.stopMem()
+				t.stopRusage()
+				t.stopRuntimeMetrics()
+			}
+			t.tracePhase("")
+			t.Active = now.Sub(t.startedAt) - t.Paused
+			t.Stats = newStats(t.Samples)
+			t.Mem = t.memNet.stats(t.Count)
+			if t.hasRusage {
+				r := t.rusageNet
+				t.Rusage = &r
+			}
+			t.Runtime = t.rtNet
+			t.Processed = t.Bytes * int64(t.Count)
 			if t.enableGC {
 				enableGC()
 			}
-			//t.profileHeap()
+			t.profileHeap()
 		}
 	}
 
+	t.setPrepareLabels()
+	t.runHooks("AfterEach", t.after)
+	t.runCleanups()
+	t.Total = time.Since(t.runOn)
+
 	return
 }
 
@@ -139,24 +212,96 @@ const PprofBufferReserve = 1024 * 1024
 func (t *Benchmark) pprofRun(f func(*T) error) {
 	var cpubuf bytes.Buffer
 	var membuf bytes.Buffer
+	var blockbuf bytes.Buffer
+	var mutexbuf bytes.Buffer
+	var blockBase, mutexBase []byte
+
+	cfg := t.config()
+
+	var blockRate int
+	if cfg.BlockProf {
+		rate := cfg.BlockProfileRate
+		if rate <= 0 {
+			rate = 1
+		}
+		blockRate = SetBlockProfileRate(rate)
+		t.onAbort(func() { SetBlockProfileRate(blockRate) })
+		blockBase = contentionSnapshot("block")
+	}
 
-	if *flagPprof || *flagCpuProf != misc.NulStr {
+	if cfg.MutexProf {
+		fraction := cfg.MutexProfileFraction
+		if fraction <= 0 {
+			fraction = 1
+		}
+		prev := runtime.SetMutexProfileFraction(fraction)
+		defer runtime.SetMutexProfileFraction(prev)
+		t.onAbort(func() { runtime.SetMutexProfileFraction(prev) })
+		mutexBase = contentionSnapshot("mutex")
+	}
+
+	if cfg.Pprof || cfg.CpuProf != "" {
 		cpubuf.Grow(PprofBufferReserve)
 		//runtime.SetCPUProfileRate(10000)
 		pprof.StartCPUProfile(&cpubuf)
+		t.onAbort(pprof.StopCPUProfile)
+	}
+
+	var task *trace.Task
+	var tracef *os.File
+	if cfg.Trace != "" {
+		var err error
+		if tracef, err = os.Create(cfg.Trace); err == nil {
+			err = trace.Start(tracef)
+			if err != nil {
+				tracef.Close()
+			}
+		}
+		if err == nil {
+			t.ctx, task = trace.NewTask(t.Context(), t.Label)
+			t.TraceFile = cfg.Trace
+			t.onAbort(func() {
+				trace.Stop()
+				tracef.Close()
+			})
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
 
 	t.run(f)
 
-	if *flagPprof || *flagCpuProf != misc.NulStr {
+	if task != nil {
+		task.End()
+		trace.Stop()
+		if err := tracef.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if sums, err := readTrace(cfg.Trace); err == nil {
+			t.setTrace(sums)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if cfg.Pprof || cfg.CpuProf != "" {
 		pprof.StopCPUProfile()
 	}
 
-	if *flagMemProf != misc.NulStr || *flagMprof {
+	if cfg.BlockProf {
+		pprof.Lookup("block").WriteTo(&blockbuf, 0)
+		SetBlockProfileRate(blockRate)
+	}
+
+	if cfg.MutexProf {
+		pprof.Lookup("mutex").WriteTo(&mutexbuf, 0)
+	}
+
+	if cfg.MemProf != "" || cfg.Mprof {
 		runtime.GC()
 		pprof.WriteHeapProfile(&membuf)
-		if *flagMemProf != misc.NulStr {
-			if f, err := os.Create(*flagMemProf); err == nil {
+		if cfg.MemProf != "" {
+			if f, err := os.Create(cfg.MemProf); err == nil {
 				f.Write(membuf.Bytes())
 			} else {
 				fmt.Fprintln(os.Stderr, err)
@@ -164,17 +309,17 @@ func (t *Benchmark) pprofRun(f func(*T) error) {
 		}
 	}
 
-	if *flagCpuProf != misc.NulStr {
-		if err := ioutil.WriteFile(*flagCpuProf, membuf.Bytes(), 0644); err != nil {
+	if cfg.CpuProf != "" {
+		if err := ioutil.WriteFile(cfg.CpuProf, membuf.Bytes(), 0644); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 		}
 	}
 
-	if *flagPprof || *flagMprof {
+	if cfg.Pprof || cfg.Mprof || cfg.BlockProf || cfg.MutexProf {
 		t.Pprof = list.New()
 
-		count := *flagNodeCount
-		pngcount := *flagCallGrapth
+		count := cfg.NodeCount
+		pngcount := cfg.CallGraph
 
 		opt := &ppf.Options{
 			Count:    count,
@@ -185,128 +330,409 @@ func (t *Benchmark) pprofRun(f func(*T) error) {
 			Hide:     []string{"google/pprof\\."},
 		}
 
-		if *flagPprof {
+		if cfg.Pprof {
 			opt.Unit = ppf.Second
 			opt.Index = ppf.CpuProfIndex
 			rpt, _ := ppf.Top(cpubuf.Bytes(), opt)
 			rpt.Label = "top"
 			t.Pprof.PushBack(rpt)
+
+			t0 := *opt
+			t0.Graph = ppf.NoImage
+			t.profileCpu(cpubuf.Bytes(), t0)
 		}
 
-		if *flagMprof {
+		if cfg.Mprof {
 			opt.Unit = ppf.None
 			opt.Index = ppf.AllocObjectsIndex
 			rpt, _ := ppf.Top(membuf.Bytes(), opt)
 			rpt.Label = "alloc"
 			t.Pprof.PushBack(rpt)
 		}
+
+		if cfg.BlockProf {
+			t.Pprof.PushBack(contentionReport("block", blockBase, blockbuf.Bytes(), *opt))
+		}
+
+		if cfg.MutexProf {
+			t.Pprof.PushBack(contentionReport("mutex", mutexBase, mutexbuf.Bytes(), *opt))
+		}
 	}
 }
 
 func Run(label string, f func(*T) error) *Benchmark {
-	if !flag.Parsed() {
-		flag.Parse()
-	}
-	runtime.LockOSThread()
-	b := &Benchmark{T: New(label)}
-	b.pprofRun(f)
-	return b
+	return defaultRunner().Run(label, f)
 }
 
 func RunWithProcessor(label string, processor func(*T, *T) *T, f func(*T) error) *Benchmark {
-	if !flag.Parsed() {
-		flag.Parse()
-	}
-	runtime.LockOSThread()
-	b := &Benchmark{T: New(label)}
-	b.processor = processor
-	b.pprofRun(f)
-	if b.processor != nil {
-		b.processor(nil, b.T)
-	}
-	return b
+	return defaultRunner().RunWithProcessor(label, processor, f)
+}
+
+func RunContext(ctx context.Context, label string, f func(*T) error) *Benchmark {
+	return defaultRunner().RunContext(ctx, label, f)
 }
 
 func (t *T) Run(label string, f func(*T) error) (err error) {
+	if cpu := t.config().Cpu; t.Procs == 0 && len(cpu) != 0 {
+		return t.RunCPU(label, cpu, f)
+	}
+	return t.runChild(t.newChild(label), f)
+}
+
+func (t *T) newChild(label string) *T {
 	t0 := New(label)
 	t0.processor = t.processor
-	if *flagPprof || *flagCpuProf != misc.NulStr {
-		defer pprof.SetGoroutineLabels(context.Background())
+	t0.samples = t.samples
+	t0.Procs = t.Procs
+	t0.ctx = t.ctx
+	t0.cfg = t.cfg
+	t0.Timeout = t.config().Timeout
+	t0.before = t.beforeEach
+	t0.after = t.afterEach
+	t0.filter = t.filter
+	t0.parent = t
+	t0.path = append(t.path[:len(t.path):len(t.path)], strings.Split(label, "/")...)
+	return t0
+}
+
+func (t *T) runChild(t0 *T, f func(*T) error) (err error) {
+	if !t0.matches(len(t.path)) {
+		if t.config().RecordFiltered {
+			t0.filtered = true
+			t.mu.Lock()
+			t.Children.PushBack(t0)
+			t.mu.Unlock()
+		}
+		return
+	}
+	defer t.setPrepareLabels()
+	var before map[string]string
+	if t.config().LeakCheck {
+		before = goroutines()
 	}
+	t.mu.Lock()
+	t.running = t0
+	t.mu.Unlock()
+	region := t.traceRegion(t0)
 	t0.run(f)
+	region.End()
+	t.mu.Lock()
+	t.running = nil
+	t.mu.Unlock()
+	if before != nil {
+		t0.checkLeaks(before)
+		for id := range t0.leaked {
+			t.reportLeak(id)
+		}
+	}
+	err = t0.Err
 	if t.processor != nil {
 		t0 = t.processor(t, t0)
 	}
 	if t0 != nil {
+		if t0.Failed() {
+			t.fail()
+		}
+		// children are read by the watchdog writing partial result
+		t.mu.Lock()
 		t.Children.PushBack(t0)
+		t.mu.Unlock()
 		t.chActive += t0.Active
+		t.chPaused += t0.Paused
+		if t0.Mem != nil {
+			t.chMem.add(memCounter{t0.Mem.Mallocs, t0.Mem.Frees, t0.Mem.Bytes})
+		}
+		if t0.Rusage != nil {
+			t.chRusage.add(*t0.Rusage)
+			t.chHasRusage = true
+		}
+		if t0.Runtime != nil {
+			addRuntimeMetrics(&t.chRuntime, t0.Runtime)
+		}
+		t.chProcessed += t0.Processed
 	}
-	return t0.Err
+	return
 }
 
 func (t *T) Start() {
 
+	if t.ctx != nil {
+		select {
+		case <-t.ctx.Done():
+			panic(errAbort)
+		default:
+		}
+	}
+
+	t.start()
+
+	if t.samples || t.config().Samples {
+		// bookkeeping of samples is excluded from samples and from Active
+		now := time.Now()
+		t.lap(now)
+		t.markedAt = time.Now()
+		t.Paused += t.markedAt.Sub(now)
+		t.markPaused = t.Paused
+	}
+
+	t.Count++
+}
+
+func (t *T) start() {
+
 	if t.Children.Len() != 0 {
 		panic("start is allowed only in leaf tasks")
 	}
 
 	if !t.isStarted {
+		if t.samples || t.config().Samples {
+			t.Samples = make([]time.Duration, 0, MaxSamples)
+		}
 		runtime.GC()
-		if *flagNoGC {
+		t.heapBase = t.heapSnapshot()
+		if t.config().NoGC {
 			t.enableGC = true
 			disableGC()
 		}
 		t.isStarted = true
+		// mem is the last snapshot as others allocate
+		t.startRusage()
+		t.startRuntimeMetrics()
+		t.setActiveLabels()
+		t.tracePhase("active")
+		t.startMem()
 		t.startedAt = time.Now()
-		pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("t", "active")))
+	} else {
+		t.Resume()
 	}
+}
 
-	t.Count++
+// Loop runs the iteration body until the leaf task has been active
+// for -benchtime, iteration count is calibrated as it goes
+//
+//	for t.Loop() {
+//		...
+//	}
+func (t *T) Loop() bool {
+	if t.loopN == 0 {
+		t.loopN = 1
+		t.Start()
+		return true
+	}
+
+	if t.ctx != nil && t.ctx.Err() != nil {
+		return false
+	}
+
+	if t.Count >= t.loopN {
+		d := time.Since(t.startedAt) - t.paused()
+		benchtime := t.config().BenchTime
+		if d >= benchtime || t.Count >= maxLoopN {
+			return false
+		}
+		t.loopN = predictN(benchtime, t.Count, d)
+	}
+
+	t.Start()
+	return true
+}
+
+const maxLoopN = 1e9
+
+func predictN(goal time.Duration, prevN int, prev time.Duration) int {
+	if prev <= 0 {
+		prev = 1
+	}
+	n := int64(goal) * int64(prevN) / int64(prev)
+	// run a bit more than predicted, but grow no more than 100x
+	n += n / 5
+	if m := 100 * int64(prevN); n > m {
+		n = m
+	}
+	if n <= int64(prevN) {
+		n = int64(prevN) + 1
+	}
+	if n > maxLoopN {
+		n = maxLoopN
+	}
+	return int(n)
+}
+
+func (t *T) NsPerOp() int64 {
+	if t.Count <= 0 {
+		return 0
+	}
+	return int64(t.Active) / int64(t.Count)
+}
+
+// SetBytes records the number of bytes processed in a single iteration
+func (t *T) SetBytes(n int64) {
+	t.Bytes = n
+}
+
+// ReportMetric records a named metric of the task, metrics are not
+// rolled up to parents as only the caller knows if they are additive
+func (t *T) ReportMetric(value float64, unit string) {
+	if t.Metrics == nil {
+		t.Metrics = make(map[string]float64)
+	}
+	t.Metrics[unit] = value
+}
+
+func (t *T) MBPerSec() float64 {
+	if t.Processed <= 0 || t.Active <= 0 {
+		return 0
+	}
+	return float64(t.Processed) / 1e6 / t.Active.Seconds()
+}
+
+func (t *T) KeepSamples() {
+	t.samples = true
+}
+
+// Lap closes the current iteration started by Start, the time until
+// the next Start is not recorded as a sample but still counts in Active
+func (t *T) Lap() {
+	t.lap(time.Now())
+}
+
+func (t *T) lap(now time.Time) {
+	if t.markedAt != (time.Time{}) {
+		t.addSample(now.Sub(t.markedAt) - (t.paused() - t.markPaused))
+		t.markedAt = time.Time{}
+	}
+}
+
+// MaxSamples limits samples kept by a task, when there are more iterations
+// samples are a uniform random subset of them
+const MaxSamples = 10000
+
+func (t *T) addSample(d time.Duration) {
+	t.laps++
+	if len(t.Samples) < MaxSamples {
+		t.Samples = append(t.Samples, d)
+	} else if i := rand.Int63n(int64(t.laps)); i < MaxSamples {
+		t.Samples[i] = d
+	}
+}
+
+// Pause stops the task timer, paused time is excluded from Active
+// and from the profile of active phase
+func (t *T) Pause() {
+	if t.isStarted && !t.isPaused {
+		t.pausedAt = time.Now()
+		t.isPaused = true
+		t.stopMem()
+		t.stopRusage()
+		t.stopRuntimeMetrics()
+		t.setPrepareLabels()
+		t.tracePhase("paused")
+	}
+}
+
+func (t *T) Resume() {
+	if t.isPaused {
+		// snapshots are taken before the clock, mem is the last as others allocate
+		t.startRusage()
+		t.startRuntimeMetrics()
+		t.setActiveLabels()
+		t.tracePhase("active")
+		t.startMem()
+		t.Paused += time.Since(t.pausedAt)
+		t.isPaused = false
+	}
+}
+
+// ResetTimer drops time and samples gathered so far, iterations count is kept
+func (t *T) ResetTimer() {
+	if t.isStarted {
+		t.Samples = t.Samples[:0]
+		t.laps = 0
+		t.memNet = memCounter{}
+		t.rusageNet = Rusage{}
+		t.rtNet = nil
+		if !t.isPaused {
+			t.startRusage()
+			t.startRuntimeMetrics()
+			t.startMem()
+		}
+		now := time.Now()
+		t.startedAt = now
+		t.Paused = 0
+		if t.isPaused {
+			t.pausedAt = now
+		}
+		if t.markedAt != (time.Time{}) {
+			t.markedAt = now
+			t.markPaused = 0
+		}
+	}
+}
+
+func (t *T) paused() time.Duration {
+	if t.isPaused {
+		return t.Paused + time.Since(t.pausedAt)
+	}
+	return t.Paused
+}
+
+func (t *T) setActiveLabels() {
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("t", "active", "path", t.Path())))
+}
+
+func (t *T) setPrepareLabels() {
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels("_", "prepare", "path", t.Path())))
 }
 
 func (t *T) Errorf(ft string, a ...interface{}) {
-	m := &Message{MsgError, fmt.Sprintf(ft, a...)}
-	t.Messages.PushBack(m)
+	t.message(MsgError, fmt.Sprintf(ft, a...))
+	t.fail()
 }
 
 func (t *T) Error(a ...interface{}) {
-	m := &Message{MsgError, fmt.Sprint(a...)}
-	t.Messages.PushBack(m)
+	t.message(MsgError, fmt.Sprint(a...))
+	t.fail()
 }
 
 func (t *T) Debugf(ft string, a ...interface{}) {
-	m := &Message{MsgDebug, fmt.Sprintf(ft, a...)}
-	t.Messages.PushBack(m)
+	t.message(MsgDebug, fmt.Sprintf(ft, a...))
 }
 
 func (t *T) Debug(a ...interface{}) {
-	m := &Message{MsgDebug, fmt.Sprint(a...)}
-	t.Messages.PushBack(m)
+	t.message(MsgDebug, fmt.Sprint(a...))
 }
 
 func (t *T) Infof(ft string, a ...interface{}) {
-	m := &Message{MsgInfo, fmt.Sprintf(ft, a...)}
-	t.Messages.PushBack(m)
+	t.message(MsgInfo, fmt.Sprintf(ft, a...))
 }
 
 func (t *T) Info(a ...interface{}) {
-	m := &Message{MsgInfo, fmt.Sprint(a...)}
-	t.Messages.PushBack(m)
+	t.message(MsgInfo, fmt.Sprint(a...))
 }
 
 func (t *T) Opt(a string) {
-	m := &Message{MsgOpt, a}
-	t.Messages.PushBack(m)
+	t.message(MsgOpt, a)
+}
+
+func (t *T) message(kind messageKind, text string) {
+	t.mu.Lock()
+	t.Messages.PushBack(&Message{kind, text})
+	t.mu.Unlock()
 }
 
 func (b *Benchmark) WriteJsonResult() (int, error) {
-	if *flagResult != misc.NulStr {
-		if f, err := os.Create(*flagResult); err != nil {
+	return b.writeResult(b.WriteJson)
+}
+
+func (b *Benchmark) writeResult(write func(io.Writer) (int, error)) (int, error) {
+	if result := b.config().Result; result != "" {
+		if f, err := os.Create(result); err != nil {
 			return 0, err
 		} else {
-			return b.WriteJson(f)
+			defer f.Close()
+			return write(f)
 		}
 	} else {
-		return b.WriteJson(os.Stderr)
+		return write(os.Stderr)
 	}
 }
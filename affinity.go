@@ -0,0 +1,73 @@
+package benchmark
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// cpuList is a set of CPUs in taskset -c syntax, i.e. 0-3,6
+type cpuList []int
+
+func (l *cpuList) String() string {
+	a := make([]string, 0, len(*l))
+	for i := 0; i < len(*l); {
+		j := i
+		for j+1 < len(*l) && (*l)[j+1] == (*l)[j]+1 {
+			j++
+		}
+		if j > i {
+			a = append(a, fmt.Sprintf("%d-%d", (*l)[i], (*l)[j]))
+		} else {
+			a = append(a, strconv.Itoa((*l)[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(a, ",")
+}
+
+func (l *cpuList) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		lo, hi := v, v
+		if i := strings.Index(v, "-"); i > 0 {
+			lo, hi = v[:i], v[i+1:]
+		}
+		a, err1 := strconv.Atoi(lo)
+		b, err2 := strconv.Atoi(hi)
+		if err1 != nil || err2 != nil || a < 0 || b < a {
+			return fmt.Errorf("invalid CPU list item %q", v)
+		}
+		for n := a; n <= b; n++ {
+			*l = append(*l, n)
+		}
+	}
+	return nil
+}
+
+// pin applies -affinity and -nice to the locked benchmark thread
+// and records effective affinity, raising priority needs privileges
+// so failure to do it is reported but the run continues
+func (b *Benchmark) pin(c *Config) (func(), error) {
+	restore := func() {}
+	if len(c.Affinity) != 0 {
+		r, err := setAffinity(c.Affinity, c.AffinityProcess)
+		if err != nil {
+			return restore, fmt.Errorf("affinity: %v", err)
+		}
+		restore = r
+	}
+	if c.Nice != 0 {
+		if r, err := setNice(c.Nice); err != nil {
+			b.Infof("nice: %v", err)
+		} else {
+			prev := restore
+			restore = func() { r(); prev() }
+		}
+	}
+	b.Affinity = readAffinity()
+	return restore, nil
+}
@@ -0,0 +1,88 @@
+package benchmark
+
+import (
+	"io/ioutil"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// setAffinity pins the calling thread, or all threads of the process,
+// new threads inherit affinity of the thread creating them
+func setAffinity(cpus []int, process bool) (func(), error) {
+	// threads are identified by tid as restore may run on another thread
+	self := unix.Gettid()
+	tids := []int{self}
+	if process {
+		fs, err := ioutil.ReadDir("/proc/self/task")
+		if err != nil {
+			return nil, err
+		}
+		tids = tids[:0]
+		for _, f := range fs {
+			if tid, err := strconv.Atoi(f.Name()); err == nil {
+				tids = append(tids, tid)
+			}
+		}
+	}
+
+	var set unix.CPUSet
+	set.Zero()
+	for _, n := range cpus {
+		set.Set(n)
+	}
+
+	saved := make(map[int]unix.CPUSet)
+	restore := func() {
+		for tid, s := range saved {
+			s := s
+			unix.SchedSetaffinity(tid, &s)
+		}
+	}
+
+	for _, tid := range tids {
+		var s unix.CPUSet
+		if err := unix.SchedGetaffinity(tid, &s); err != nil {
+			continue // thread has exited
+		}
+		if err := unix.SchedSetaffinity(tid, &set); err != nil {
+			if tid == self || err != unix.ESRCH {
+				restore()
+				return nil, err
+			}
+			continue
+		}
+		saved[tid] = s
+	}
+	return restore, nil
+}
+
+// setNice sets priority of the calling thread, negative values raise it
+func setNice(nice int) (func(), error) {
+	tid := unix.Gettid()
+	prio, err := unix.Getpriority(unix.PRIO_PROCESS, tid)
+	if err != nil {
+		return nil, err
+	}
+	// syscall returns 20-nice to avoid negative values
+	prev := 20 - prio
+	if err := unix.Setpriority(unix.PRIO_PROCESS, tid, nice); err != nil {
+		return nil, err
+	}
+	return func() { unix.Setpriority(unix.PRIO_PROCESS, tid, prev) }, nil
+}
+
+func readAffinity() []int {
+	var s unix.CPUSet
+	if unix.SchedGetaffinity(0, &s) != nil {
+		return nil
+	}
+	var l []int
+	for i, n := 0, s.Count(); n > 0; i++ {
+		if s.IsSet(i) {
+			l = append(l, i)
+			n--
+		}
+	}
+	return l
+}
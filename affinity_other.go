@@ -0,0 +1,19 @@
+//go:build !linux
+
+package benchmark
+
+import "errors"
+
+var errNoAffinity = errors.New("is not supported on this platform")
+
+func setAffinity(cpus []int, process bool) (func(), error) {
+	return nil, errNoAffinity
+}
+
+func setNice(nice int) (func(), error) {
+	return nil, errNoAffinity
+}
+
+func readAffinity() []int {
+	return nil
+}
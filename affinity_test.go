@@ -0,0 +1,59 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCpuList(t *testing.T) {
+	var l cpuList
+	if err := l.Set("0-3,6,8-9"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]int(l), []int{0, 1, 2, 3, 6, 8, 9}) {
+		t.Errorf("invalid CPU list %v", l)
+	}
+	if s := l.String(); s != "0-3,6,8-9" {
+		t.Errorf("invalid CPU list string %v", s)
+	}
+	if err := l.Set("3-1"); err == nil {
+		t.Errorf("invalid range is accepted")
+	}
+}
+
+func TestAffinity(t *testing.T) {
+	cpus := readAffinity()
+	if len(cpus) == 0 {
+		t.Skip("affinity is not supported")
+	}
+
+	c := DefaultConfig()
+	c.Affinity = cpus[len(cpus)-1:]
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItPins", func(t *T) error {
+			t.Start()
+			return nil
+		})
+	})
+
+	if !reflect.DeepEqual(b.Affinity, c.Affinity) {
+		t.Errorf("effective affinity %v is not %v", b.Affinity, c.Affinity)
+	}
+	if a := readAffinity(); !reflect.DeepEqual(a, cpus) {
+		t.Errorf("affinity %v is not restored to %v", a, cpus)
+	}
+
+	bs, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b0 := &Benchmark{}
+	if err := json.Unmarshal(bs, b0); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b0.Affinity, b.Affinity) {
+		t.Errorf("affinity is not unmarshaled %v", b0.Affinity)
+	}
+}
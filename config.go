@@ -0,0 +1,224 @@
+package benchmark
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	NoGC      bool
+	Pprof     bool
+	Mprof     bool
+	CpuProf   string
+	MemProf   string
+	CallGraph int
+	NodeCount int
+	Result    string
+	Samples   bool
+	BenchTime time.Duration
+	Timeout   time.Duration
+	Cpu       []int
+
+	Bench          string
+	RecordFiltered bool
+
+	Watchdog      time.Duration
+	WatchdogAbort bool
+
+	LeakCheck bool
+	LeakGrace time.Duration
+
+	HeapProf       bool
+	MemProfileRate int
+
+	BlockProf            bool
+	BlockProfileRate     int
+	MutexProf            bool
+	MutexProfileFraction int
+
+	Trace string
+
+	Affinity        []int
+	AffinityProcess bool
+	Nice            int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		NodeCount: 20,
+		BenchTime: time.Second,
+		LeakGrace: 100 * time.Millisecond,
+	}
+}
+
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.NoGC, "nogc", c.NoGC, "disable GC on benchmark")
+	fs.BoolVar(&c.Pprof, "pprof", c.Pprof, "profile benchmarks")
+	fs.BoolVar(&c.Mprof, "mprof", c.Mprof, "profile memory allocations")
+	fs.StringVar(&c.CpuProf, "cpuprof", c.CpuProf, "where to store cpuprofile")
+	fs.StringVar(&c.MemProf, "memprof", c.MemProf, "where to store memprofile")
+	fs.IntVar(&c.CallGraph, "callgraph", c.CallGraph, "count of nodes to write PNG callgraph")
+	fs.IntVar(&c.NodeCount, "nodecount", c.NodeCount, "count of nodes to gather top calls")
+	fs.StringVar(&c.Result, "result", c.Result, "file name to write the benchmarking result")
+	fs.BoolVar(&c.Samples, "samples", c.Samples, "record per-iteration durations")
+	fs.DurationVar(&c.BenchTime, "benchtime", c.BenchTime, "target duration of calibrated leaf tasks")
+	fs.DurationVar(&c.Timeout, "timeout", c.Timeout, "timeout of every task, 0 means no timeout")
+	fs.Var((*procsList)(&c.Cpu), "cpu", "comma-separated list of GOMAXPROCS values to run top-level tasks with")
+	fs.StringVar(&c.Bench, "bench", c.Bench, "slash-separated regexp selecting tasks to run by their label path")
+	fs.BoolVar(&c.RecordFiltered, "recordfiltered", c.RecordFiltered, "record tasks skipped by -bench as filtered")
+	fs.DurationVar(&c.Watchdog, "watchdog", c.Watchdog, "dump goroutines of a task running longer, 0 means no watchdog")
+	fs.BoolVar(&c.WatchdogAbort, "watchdogabort", c.WatchdogAbort, "write partial result and exit when watchdog fires")
+	fs.BoolVar(&c.LeakCheck, "leakcheck", c.LeakCheck, "fail tasks leaving running goroutines")
+	fs.DurationVar(&c.LeakGrace, "leakgrace", c.LeakGrace, "time to wait for goroutines of a finished task")
+	fs.BoolVar(&c.HeapProf, "heapprof", c.HeapProf, "profile heap of every leaf task")
+	fs.IntVar(&c.MemProfileRate, "memprofilerate", c.MemProfileRate, "set runtime.MemProfileRate for the run, 0 keeps default")
+	fs.BoolVar(&c.BlockProf, "blockprof", c.BlockProf, "profile blocking on synchronization primitives")
+	fs.IntVar(&c.BlockProfileRate, "blockprofilerate", c.BlockProfileRate, "runtime.SetBlockProfileRate for -blockprof, 0 means every event")
+	fs.BoolVar(&c.MutexProf, "mutexprof", c.MutexProf, "profile mutex contention")
+	fs.IntVar(&c.MutexProfileFraction, "mutexprofilefraction", c.MutexProfileFraction, "runtime.SetMutexProfileFraction for -mutexprof, 0 means every event")
+	fs.StringVar(&c.Trace, "trace", c.Trace, "where to store execution trace with a region for every task")
+	fs.Var((*cpuList)(&c.Affinity), "affinity", "CPU list to pin the benchmark thread to, i.e. 0-3,6")
+	fs.BoolVar(&c.AffinityProcess, "affinityprocess", c.AffinityProcess, "pin all threads of the process by -affinity")
+	fs.IntVar(&c.Nice, "nice", c.Nice, "nice value of the benchmark thread, negative raises priority where permitted")
+}
+
+const EnvPrefix = "BENCHMARK_"
+
+// FromEnv overrides options by BENCHMARK_<FLAG> environment variables,
+// values have the same syntax as flags, i.e. BENCHMARK_BENCHTIME=3s
+func (c *Config) FromEnv() error {
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := EnvPrefix + strings.ToUpper(f.Name)
+		if v, ok := os.LookupEnv(name); ok && err == nil {
+			if e := f.Value.Set(v); e != nil {
+				err = fmt.Errorf("invalid %s value %q: %v", name, v, e)
+			}
+		}
+	})
+	return err
+}
+
+// flags formats options by names and syntax of their flags
+func (c *Config) flags() map[string]string {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+	m := make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		m[f.Name] = f.Value.String()
+	})
+	return m
+}
+
+var (
+	pkgConfig     Config
+	pkgConfigOnce sync.Once
+)
+
+// packageConfig is used by package level functions and tasks created by New,
+// it's built on first use by defaults and environment
+func packageConfig() *Config {
+	pkgConfigOnce.Do(func() {
+		pkgConfig = DefaultConfig()
+		if err := pkgConfig.FromEnv(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+	return &pkgConfig
+}
+
+// RegisterFlags binds options of package level functions to flags of fs,
+// package level Run binds them to the command line by itself, a program parsing
+// the command line before Run calls RegisterFlags(flag.CommandLine) before flag.Parse
+func RegisterFlags(fs *flag.FlagSet) {
+	packageConfig().RegisterFlags(fs)
+}
+
+type Runner struct {
+	Config
+}
+
+func NewRunner(c Config) *Runner {
+	return &Runner{c}
+}
+
+func (r *Runner) Run(label string, f func(*T) error) *Benchmark {
+	return r.run(context.Background(), label, nil, f)
+}
+
+func (r *Runner) RunWithProcessor(label string, processor func(*T, *T) *T, f func(*T) error) *Benchmark {
+	return r.run(context.Background(), label, processor, f)
+}
+
+func (r *Runner) RunContext(ctx context.Context, label string, f func(*T) error) *Benchmark {
+	return r.run(ctx, label, nil, f)
+}
+
+func (r *Runner) run(ctx context.Context, label string, processor func(*T, *T) *T, f func(*T) error) *Benchmark {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	c := r.Config
+	if c.MemProfileRate > 0 {
+		defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+		runtime.MemProfileRate = c.MemProfileRate
+	}
+	b := &Benchmark{T: New(label), Metadata: newMetadata(&c)}
+	b.cfg = &c
+	b.ctx = ctx
+	b.processor = processor
+	b.bench = b
+	if restore, err := b.pin(&c); err != nil {
+		b.Err = err
+		return b
+	} else {
+		defer restore()
+		b.onAbort(restore)
+	}
+	if filter, err := compileFilter(c.Bench); err != nil {
+		b.Err = err
+		return b
+	} else {
+		b.filter = filter
+	}
+	b.pprofRun(f)
+	if b.processor != nil {
+		b.processor(nil, b.T)
+	}
+	return b
+}
+
+var commandLineOnce sync.Once
+
+// defaultRunner is used by package level functions, they keep options
+// on the command line: flags not defined by the program are bound to
+// the package config and the command line is parsed if it's not yet
+func defaultRunner() *Runner {
+	commandLineOnce.Do(func() {
+		fs := flag.NewFlagSet("benchmark", flag.ContinueOnError)
+		RegisterFlags(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			if flag.CommandLine.Lookup(f.Name) == nil {
+				flag.CommandLine.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	})
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	return NewRunner(*packageConfig())
+}
+
+func (t *T) config() *Config {
+	if t.cfg == nil {
+		return packageConfig()
+	}
+	return t.cfg
+}
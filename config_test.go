@@ -0,0 +1,85 @@
+package benchmark
+
+import (
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	os.Setenv("BENCHMARK_BENCHTIME", "10ms")
+	os.Setenv("BENCHMARK_CPU", "1,2")
+	defer os.Unsetenv("BENCHMARK_BENCHTIME")
+	defer os.Unsetenv("BENCHMARK_CPU")
+
+	c := DefaultConfig()
+	if err := c.FromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if c.BenchTime != 10*time.Millisecond {
+		t.Errorf("benchtime is not matched %v", c.BenchTime)
+	}
+	if !reflect.DeepEqual(c.Cpu, []int{1, 2}) {
+		t.Errorf("cpu is not matched %v", c.Cpu)
+	}
+
+	os.Setenv("BENCHMARK_NODECOUNT", "many")
+	defer os.Unsetenv("BENCHMARK_NODECOUNT")
+	if err := c.FromEnv(); err == nil {
+		t.Error("invalid value must be reported")
+	}
+}
+
+func TestRunner(t *testing.T) {
+	c := DefaultConfig()
+	c.BenchTime = 10 * time.Millisecond
+	c.Cpu = []int{1, 2}
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItLoops", func(t *T) error {
+			for t.Loop() {
+			}
+			return nil
+		})
+	})
+
+	if b.Children.Len() != 2 {
+		t.Fatalf("children count is not matched %v", b.Children.Len())
+	}
+	for e := b.Children.Front(); e != nil; e = e.Next() {
+		t0 := e.Value.(*T)
+		if t0.Active < c.BenchTime || t0.Active > 10*c.BenchTime {
+			t.Errorf("%s: active time %v is not matched benchtime", t0.Label, t0.Active)
+		}
+	}
+}
+
+func countCommandLineFlags() (n int) {
+	flag.CommandLine.VisitAll(func(*flag.Flag) { n++ })
+	return
+}
+
+func TestNoCommandLineFlags(t *testing.T) {
+	n := countCommandLineFlags()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	NewRunner(DefaultConfig()).Run(".", func(t *T) error {
+		return nil
+	})
+	if n0 := countCommandLineFlags(); n0 != n {
+		t.Errorf("%d flags are registered on the command line", n0-n)
+	}
+}
+
+func TestCommandLineFlags(t *testing.T) {
+	Run(".", func(t *T) error {
+		return nil
+	})
+	for _, name := range []string{"pprof", "mprof", "callgraph", "result"} {
+		if flag.CommandLine.Lookup(name) == nil {
+			t.Errorf("flag %s is not registered on the command line", name)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package benchmark
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"sync/atomic"
+
+	ppf "github.com/sudachen/benchmark/ppftool"
+)
+
+var blockProfileRate int64
+
+// SetBlockProfileRate calls runtime.SetBlockProfileRate and returns the previous
+// rate, runtime has no getter of the rate so -blockprof restores the rate set
+// by this function, a host keeping block profiling on sets it here
+func SetBlockProfileRate(rate int) int {
+	prev := atomic.SwapInt64(&blockProfileRate, int64(rate))
+	runtime.SetBlockProfileRate(rate)
+	return int(prev)
+}
+
+// contentionSnapshot is taken when profiling starts, block and mutex
+// profiles are cumulative since the process started
+func contentionSnapshot(name string) []byte {
+	var bf bytes.Buffer
+	pprof.Lookup(name).WriteTo(&bf, 0)
+	return bf.Bytes()
+}
+
+// contentionReport builds top of block or mutex profile by delay since base,
+// the profile is empty if nothing was contended so errors are kept in report
+func contentionReport(label string, base, b []byte, opt ppf.Options) *ppf.Report {
+	opt.Unit = ppf.Millisecond
+	opt.Index = ppf.DelayIndex
+	b, err := ppf.Diff(base, b)
+	var rpt *ppf.Report
+	if err == nil {
+		rpt, err = ppf.Top(b, &opt)
+	}
+	if err != nil {
+		rpt = &ppf.Report{Unit: opt.Unit, Errors: []string{err.Error()}}
+	}
+	rpt.Label = label
+	return rpt
+}
@@ -0,0 +1,88 @@
+package benchmark
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ppf "github.com/sudachen/benchmark/ppftool"
+)
+
+func contendForProfile() {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mu.Lock()
+				time.Sleep(100 * time.Microsecond)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestContention(t *testing.T) {
+	c := DefaultConfig()
+	c.BlockProf = true
+	c.MutexProf = true
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItContends", func(t *T) error {
+			t.Start()
+			contendForProfile()
+			return nil
+		})
+	})
+
+	labels := map[string]bool{}
+	for e := b.Pprof.Front(); e != nil; e = e.Next() {
+		rpt := e.Value.(*ppf.Report)
+		labels[rpt.Label] = true
+		found := false
+		for _, r := range rpt.Rows {
+			found = found || strings.Contains(r.Function, "contendForProfile")
+		}
+		if !found {
+			t.Errorf("%v report has no contention of the task %v %v", rpt.Label, rpt.Rows, rpt.Errors)
+		}
+	}
+	if !labels["block"] || !labels["mutex"] {
+		t.Errorf("contention reports are missing %v", labels)
+	}
+
+	b = NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItIdles", func(t *T) error {
+			t.Start()
+			return nil
+		})
+	})
+
+	for e := b.Pprof.Front(); e != nil; e = e.Next() {
+		rpt := e.Value.(*ppf.Report)
+		for _, r := range rpt.Rows {
+			if strings.Contains(r.Function, "contendForProfile") {
+				t.Errorf("%v report has contention of the previous run %v", rpt.Label, r)
+			}
+		}
+	}
+}
+
+func TestContentionRestoresRate(t *testing.T) {
+	SetBlockProfileRate(1000)
+	defer SetBlockProfileRate(0)
+
+	c := DefaultConfig()
+	c.BlockProf = true
+	NewRunner(c).Run(".", func(t *T) error {
+		return nil
+	})
+
+	if rate := SetBlockProfileRate(0); rate != 1000 {
+		t.Errorf("block profile rate %v is not restored", rate)
+	}
+}
@@ -0,0 +1,51 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout after %v", e.Timeout)
+}
+
+// Context is cancelled when the task exceeds its timeout or the context
+// passed to RunContext is cancelled, Loop and PB.Next stop iterating then
+// and Start aborts the task. Only these and code watching the context are
+// bounded, a body blocked in a call ignoring the context runs until it returns
+func (t *T) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+func (t *T) RunTimeout(label string, timeout time.Duration, f func(*T) error) error {
+	t0 := t.newChild(label)
+	t0.Timeout = timeout
+	return t.runChild(t0, f)
+}
+
+func (t *T) checkContext() {
+	switch t.ctx.Err() {
+	case context.DeadlineExceeded:
+		t.TimedOut = true
+		switch {
+		case t.Err != nil && t.Err != t.ctx.Err():
+			// an error returned or recovered from the task is kept
+		case t.Timeout > 0 && time.Since(t.runOn) >= t.Timeout:
+			t.Err = &TimeoutError{t.Timeout}
+		default:
+			t.Err = t.ctx.Err()
+		}
+	case context.Canceled:
+		if t.Err == nil {
+			t.Err = t.ctx.Err()
+		}
+	}
+}
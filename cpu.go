@@ -0,0 +1,49 @@
+package benchmark
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+type procsList []int
+
+func (l *procsList) String() string {
+	a := make([]string, len(*l))
+	for i, n := range *l {
+		a[i] = strconv.Itoa(n)
+	}
+	return strings.Join(a, ",")
+}
+
+func (l *procsList) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid GOMAXPROCS value %q", v)
+		}
+		*l = append(*l, n)
+	}
+	return nil
+}
+
+// RunCPU runs the task once for every GOMAXPROCS value,
+// children are labelled as label-N
+func (t *T) RunCPU(label string, procs []int, f func(*T) error) (err error) {
+	for _, n := range procs {
+		prev := runtime.GOMAXPROCS(n)
+		t0 := t.newChild(fmt.Sprintf("%s-%d", label, n))
+		t0.Procs = n
+		e := t.runChild(t0, f)
+		runtime.GOMAXPROCS(prev)
+		if err == nil {
+			err = e
+		}
+	}
+	return
+}
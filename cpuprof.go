@@ -0,0 +1,32 @@
+package benchmark
+
+import (
+	"regexp"
+	"strings"
+
+	ppf "github.com/sudachen/benchmark/ppftool"
+)
+
+// taskTagFocus selects samples of active phase of the task and its subtasks,
+// pprof splits tagfocus by commas and the key by = so they are escaped in the path
+func taskTagFocus(path string) string {
+	p := strings.NewReplacer(",", `\x2c`, "=", `\x3d`).Replace(regexp.QuoteMeta(path))
+	return `^t:active$,^path:` + p + `(/|$)`
+}
+
+// profileCpu fills Cpu of every task by the shared CPU profile
+// focused on samples labelled with the task path
+func (t *T) profileCpu(b []byte, opt ppf.Options) {
+	for e := t.Children.Front(); e != nil; e = e.Next() {
+		t0 := e.Value.(*T)
+		if t0.filtered {
+			continue
+		}
+		opt.TagFocus = []string{taskTagFocus(t0.Path())}
+		if rpt, err := ppf.Top(b, &opt); err == nil {
+			rpt.Label = "cpu"
+			t0.Cpu = rpt
+		}
+		t0.profileCpu(b, opt)
+	}
+}
@@ -0,0 +1,61 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var cpuSink int
+
+func spinForCpuProfile(d time.Duration) {
+	for t0 := time.Now(); time.Since(t0) < d; {
+		for i := 0; i < 1000; i++ {
+			cpuSink += i * i
+		}
+	}
+}
+
+func TestCpuByTask(t *testing.T) {
+	c := DefaultConfig()
+	c.Pprof = true
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		t.Run("ItSpins", func(t *T) error {
+			t.Start()
+			spinForCpuProfile(300 * time.Millisecond)
+			return nil
+		})
+		t.RunMatrix([]Axis{{"size", []interface{}{"1k"}}, {"codec", []interface{}{"a"}}}, func(t *T) error {
+			t.Start()
+			spinForCpuProfile(300 * time.Millisecond)
+			return nil
+		})
+		return t.Run("ItSleeps", func(t *T) error {
+			t.Start()
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	})
+
+	for e := b.Children.Front(); e != b.Children.Back(); e = e.Next() {
+		c0 := e.Value.(*T)
+		if c0.Cpu == nil {
+			t.Fatalf("cpu is not profiled by task %v", c0.Label)
+		}
+		found := false
+		for _, r := range c0.Cpu.Rows {
+			found = found || strings.Contains(r.Function, "spinForCpuProfile")
+		}
+		if !found {
+			t.Errorf("cpu samples are not attributed to the task %v %v", c0.Label, c0.Cpu.Rows)
+		}
+	}
+	if c1 := b.Children.Back().Value.(*T); c1.Cpu != nil {
+		for _, r := range c1.Cpu.Rows {
+			if strings.Contains(r.Function, "spinForCpuProfile") {
+				t.Errorf("cpu samples of sibling leak into the task %v", c1.Cpu.Rows)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package benchmark
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+func compileFilter(pattern string) ([]*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	a := strings.Split(pattern, "/")
+	filter := make([]*regexp.Regexp, len(a))
+	for i, s := range a {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %v", pattern, err)
+		}
+		filter[i] = re
+	}
+	return filter, nil
+}
+
+// Path is the slash-separated path of task labels below the root
+func (t *T) Path() string {
+	return strings.Join(t.path, "/")
+}
+
+// matches checks path elements added by the task, parent elements are already matched
+func (t *T) matches(depth int) bool {
+	for i := depth; i < len(t.path) && i < len(t.filter); i++ {
+		if !t.filter[i].MatchString(t.path[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package benchmark
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	c := DefaultConfig()
+	c.Bench = "Sort|Noop/size=1k/codec=a"
+	c.RecordFiltered = true
+
+	var executed []string
+	f := func(t *T) error {
+		executed = append(executed, t.Path())
+		return nil
+	}
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		t.Run("Sort", func(t *T) error {
+			return t.RunMatrix([]Axis{
+				{"size", []interface{}{"1k", "1M"}},
+				{"codec", []interface{}{"a", "b"}},
+			}, f)
+		})
+		t.Run("Search", f)
+		t.Run("Noop", f)
+		return nil
+	})
+
+	if !reflect.DeepEqual(executed, []string{"Sort/size=1k/codec=a", "Noop"}) {
+		t.Errorf("executed tasks are not matched %v", executed)
+	}
+	if b.Children.Len() != 3 {
+		t.Errorf("filtered tasks are not recorded")
+	}
+	if s := b.Children.Front().Next().Value.(*T).status(); s != "filtered" {
+		t.Errorf("status %s is not matched", s)
+	}
+}
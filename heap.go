@@ -0,0 +1,61 @@
+package benchmark
+
+import (
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+
+	ppf "github.com/sudachen/benchmark/ppftool"
+)
+
+// heapSnapshot is taken after GC, heap profile shows the state
+// as of the most recently completed GC
+func (t *T) heapSnapshot() []byte {
+	if !t.config().HeapProf {
+		return nil
+	}
+	var bf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&bf, 0); err != nil {
+		t.Debugf("heap profile: %v", err)
+		return nil
+	}
+	return bf.Bytes()
+}
+
+// profileHeap fills Heap by allocations of the leaf task
+// and HeapInuse by the growth of in-use memory
+func (t *T) profileHeap() {
+	if t.heapBase == nil {
+		return
+	}
+
+	runtime.GC()
+	b, err := ppf.Diff(t.heapBase, t.heapSnapshot())
+	t.heapBase = nil
+	if err != nil {
+		t.Debugf("heap profile: %v", err)
+		return
+	}
+
+	opt := &ppf.Options{
+		Unit:  ppf.Kilobyte,
+		Count: t.config().NodeCount,
+		Hide:  []string{"google/pprof\\."},
+	}
+
+	opt.Index = ppf.AllocSpaceIndex
+	if rpt, err := ppf.Top(b, opt); err == nil {
+		rpt.Label = "heap"
+		t.Heap = rpt
+	} else {
+		t.Debugf("heap profile: %v", err)
+	}
+
+	opt.Index = ppf.InuseSpaceIndex
+	if rpt, err := ppf.Top(b, opt); err == nil {
+		rpt.Label = "heap_inuse"
+		t.HeapInuse = rpt
+	} else {
+		t.Debugf("heap profile: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+var heapSink [][]byte
+
+func allocateForHeapProfile() {
+	for i := 0; i < 1000; i++ {
+		heapSink = append(heapSink, make([]byte, 1024))
+	}
+}
+
+func TestHeapProf(t *testing.T) {
+	c := DefaultConfig()
+	c.HeapProf = true
+	c.MemProfileRate = 1
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItAllocates", func(t *T) error {
+			t.Start()
+			allocateForHeapProfile()
+			return nil
+		})
+	})
+	heapSink = nil
+
+	c0 := b.Children.Front().Value.(*T)
+	if c0.Heap == nil || c0.HeapInuse == nil {
+		t.Fatalf("heap is not profiled %v", c0.Messages.Len())
+	}
+	found := false
+	for _, r := range c0.Heap.Rows {
+		found = found || strings.Contains(r.Function, "allocateForHeapProfile")
+	}
+	if !found {
+		t.Errorf("allocations are not attributed to the task %v", c0.Heap.Rows)
+	}
+}
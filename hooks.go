@@ -0,0 +1,57 @@
+package benchmark
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// Cleanup registers a function to be called when the task completes,
+// cleanups are called in last added, first called order
+func (t *T) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+// BeforeEach registers a hook called for every child started by Run
+// before the child function, its time is not counted in Active
+func (t *T) BeforeEach(f func(*T) error) {
+	t.beforeEach = append(t.beforeEach, f)
+}
+
+// AfterEach registers a hook called for every child started by Run
+// after the child function, its time is not counted in Active
+func (t *T) AfterEach(f func(*T) error) {
+	t.afterEach = append(t.afterEach, f)
+}
+
+// TempDir returns a new temporary directory which is removed on task completion
+func (t *T) TempDir() string {
+	dir, err := ioutil.TempDir("", "benchmark")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Errorf("TempDir: %v", err)
+		}
+	})
+	return dir
+}
+
+func (t *T) runHooks(name string, hooks []func(*T) error) {
+	for _, f := range hooks {
+		if err := t.call(f); err != nil {
+			t.Errorf("%s: %v", name, err)
+			if t.Err == nil {
+				t.Err = err
+			}
+		}
+	}
+}
+
+func (t *T) runCleanups() {
+	for len(t.cleanups) != 0 {
+		f := t.cleanups[len(t.cleanups)-1]
+		t.cleanups = t.cleanups[:len(t.cleanups)-1]
+		t.runHooks("Cleanup", []func(*T) error{func(*T) error { f(); return nil }})
+	}
+}
@@ -2,6 +2,7 @@ package benchmark
 
 import (
 	"container/list"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,6 +28,8 @@ func (k *messageKind) fromString(s string) error {
 		*k = MsgDebug
 	case "MsgOpt":
 		*k = MsgOpt
+	case "MsgSkip":
+		*k = MsgSkip
 	default:
 		return fmt.Errorf("invalid message kind %s", s)
 	}
@@ -34,7 +37,11 @@ func (k *messageKind) fromString(s string) error {
 }
 
 func (b *Benchmark) toMap() map[string]interface{} {
-	m := b.T.toMap()
+	return b.extendMap(b.T.toMap())
+}
+
+// extendMap adds fields of the run to the map of the root task
+func (b *Benchmark) extendMap(m map[string]interface{}) map[string]interface{} {
 
 	if b.Pprof != nil && b.Pprof.Len() != 0 {
 		f := make([]interface{}, 0, b.Pprof.Len())
@@ -46,48 +53,442 @@ func (b *Benchmark) toMap() map[string]interface{} {
 		m["pprof"] = f
 	}
 
+	if len(b.Affinity) != 0 {
+		m["affinity"] = (*cpuList)(&b.Affinity).String()
+	}
+
+	if b.Metadata != nil {
+		m["metadata"] = b.Metadata.toMap()
+	}
+
+	if b.TraceFile != "" {
+		m["trace_file"] = b.TraceFile
+	}
+
+	return m
+}
+
+// partialMap describes an unfinished task in the partial result written
+// by the watchdog, the task goroutine still mutates its counters so only
+// fields guarded by the mutex are written and the task is reported as failed
+func (t *T) partialMap() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m := make(map[string]interface{})
+	m["label"] = t.Label
+	m["status"] = "failed"
+	m["count"] = "0"
+	m["active"] = "0"
+	m["total"] = "0"
+
+	children := make([]interface{}, 0, t.Children.Len()+1)
+	for e := t.Children.Front(); e != nil; e = e.Next() {
+		children = append(children, e.Value)
+	}
+	if t.running != nil {
+		children = append(children, t.running.partialMap())
+	}
+	if len(children) != 0 {
+		m["children"] = children
+	}
+
+	if t.Messages.Len() != 0 {
+		m["messages"] = t.messagesToList()
+	}
+
+	if len(t.Artifacts) != 0 {
+		m["artifacts"] = t.artifactsToMap()
+	}
+
 	return m
 }
 
+func (t *T) messagesToList() []interface{} {
+	messages := make([]interface{}, 0, t.Messages.Len())
+	for e := t.Messages.Front(); e != nil; e = e.Next() {
+		msg := e.Value.(*Message)
+		v := make(map[string]string)
+		v["kind"] = msg.Kind.String()
+		v["text"] = msg.Text
+		messages = append(messages, v)
+	}
+	return messages
+}
+
+func (t *T) artifactsToMap() map[string]string {
+	artifacts := make(map[string]string)
+	for name, data := range t.Artifacts {
+		artifacts[name] = base64.StdEncoding.EncodeToString(data)
+	}
+	return artifacts
+}
+
 func (t *T) toMap() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	m := make(map[string]interface{})
 
 	m["label"] = t.Label
+	m["status"] = t.status()
 	m["count"] = fmt.Sprintf("%v", t.Count)
 	m["active"] = fmt.Sprintf("%v", uint64(t.Active))
 	m["total"] = fmt.Sprintf("%v", uint64(t.Total))
+	m["paused"] = fmt.Sprintf("%v", uint64(t.Paused))
+
+	if t.Count > 0 {
+		m["ns/op"] = fmt.Sprintf("%v", t.NsPerOp())
+	}
+
+	if t.Procs > 0 {
+		m["procs"] = fmt.Sprintf("%v", t.Procs)
+	}
+
+	if len(t.Params) != 0 {
+		params := make(map[string]string, len(t.Params))
+		for name, v := range t.Params {
+			params[name] = fmt.Sprint(v)
+		}
+		m["params"] = params
+	}
+
+	if t.Timeout > 0 {
+		m["timeout"] = fmt.Sprintf("%v", uint64(t.Timeout))
+	}
+
+	if t.TimedOut {
+		m["timedout"] = "true"
+	}
 
 	if t.Err != nil {
 		m["error"] = t.Err.Error()
 	}
 
-	if t.Children != nil && t.Children.Len() != 0 {
-		children := make([]interface{}, 0, t.Children.Len())
+	if t.Stack != "" {
+		m["stack"] = t.Stack
+	}
+
+	if t.Children != nil && (t.Children.Len() != 0 || t.running != nil) {
+		children := make([]interface{}, 0, t.Children.Len()+1)
 		for e := t.Children.Front(); e != nil; e = e.Next() {
 			children = append(children, e.Value)
 		}
+		// unfinished child appears only in the partial result written by watchdog
+		if t.running != nil {
+			children = append(children, t.running.partialMap())
+		}
 		m["children"] = children
 	}
 
 	if t.Messages != nil && t.Messages.Len() != 0 {
-		messages := make([]interface{}, 0, t.Messages.Len())
-		for e := t.Messages.Front(); e != nil; e = e.Next() {
-			msg := e.Value.(*Message)
-			v := make(map[string]string)
-			v["kind"] = msg.Kind.String()
-			v["text"] = msg.Text
-			messages = append(messages, v)
-		}
-		m["messages"] = messages
+		m["messages"] = t.messagesToList()
 	}
 
 	if t.Heap != nil {
 		m["heap"] = t.Heap.ToMap()
 	}
 
+	if t.HeapInuse != nil {
+		m["heap_inuse"] = t.HeapInuse.ToMap()
+	}
+
+	if t.Cpu != nil {
+		m["cpu"] = t.Cpu.ToMap()
+	}
+
+	if len(t.Artifacts) != 0 {
+		m["artifacts"] = t.artifactsToMap()
+	}
+
+	if t.Stats != nil {
+		m["stats"] = t.Stats.toMap()
+	}
+
+	if t.Mem != nil {
+		m["mem"] = t.Mem.toMap()
+	}
+
+	if t.Rusage != nil {
+		m["rusage"] = t.Rusage.toMap()
+	}
+
+	if t.Trace != nil {
+		m["trace"] = t.Trace.toMap()
+	}
+
+	if len(t.Runtime) != 0 {
+		rt := make(map[string]interface{})
+		for name, rm := range t.Runtime {
+			rt[name] = rm.toMap()
+		}
+		m["runtime"] = rt
+	}
+
+	if t.Bytes > 0 {
+		m["bytes"] = fmt.Sprintf("%v", t.Bytes)
+	}
+
+	if t.Processed > 0 {
+		m["processed"] = fmt.Sprintf("%v", t.Processed)
+		m["mb/s"] = strconv.FormatFloat(t.MBPerSec(), 'f', 2, 64)
+	}
+
+	if len(t.Metrics) != 0 {
+		metrics := make(map[string]string)
+		for unit, v := range t.Metrics {
+			metrics[unit] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		m["metrics"] = metrics
+	}
+
+	return m
+}
+
+func (s *Stats) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	m["min"] = fmt.Sprintf("%v", uint64(s.Min))
+	m["max"] = fmt.Sprintf("%v", uint64(s.Max))
+	m["mean"] = fmt.Sprintf("%v", uint64(s.Mean))
+	m["stddev"] = fmt.Sprintf("%v", uint64(s.Stddev))
+	m["median"] = fmt.Sprintf("%v", uint64(s.Median))
+	m["p90"] = fmt.Sprintf("%v", uint64(s.P90))
+	m["p99"] = fmt.Sprintf("%v", uint64(s.P99))
+	return m
+}
+
+func (s *Stats) fromMap(m map[string]interface{}) error {
+	for k, p := range map[string]*time.Duration{
+		"min":    &s.Min,
+		"max":    &s.Max,
+		"mean":   &s.Mean,
+		"stddev": &s.Stddev,
+		"median": &s.Median,
+		"p90":    &s.P90,
+		"p99":    &s.P99,
+	} {
+		if v, err := strconv.ParseInt(m[k].(string), 10, 64); err != nil {
+			return err
+		} else {
+			*p = time.Duration(v)
+		}
+	}
+	return nil
+}
+
+func (ms *MemStats) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	m["mallocs"] = fmt.Sprintf("%v", ms.Mallocs)
+	m["frees"] = fmt.Sprintf("%v", ms.Frees)
+	m["bytes"] = fmt.Sprintf("%v", ms.Bytes)
+	m["allocs/op"] = fmt.Sprintf("%v", ms.AllocsPerOp)
+	m["bytes/op"] = fmt.Sprintf("%v", ms.BytesPerOp)
 	return m
 }
 
+func (ms *MemStats) fromMap(m map[string]interface{}) error {
+	for k, p := range map[string]*uint64{
+		"mallocs":   &ms.Mallocs,
+		"frees":     &ms.Frees,
+		"bytes":     &ms.Bytes,
+		"allocs/op": &ms.AllocsPerOp,
+		"bytes/op":  &ms.BytesPerOp,
+	} {
+		if v, err := strconv.ParseUint(m[k].(string), 10, 64); err != nil {
+			return err
+		} else {
+			*p = v
+		}
+	}
+	return nil
+}
+
+func (r *Rusage) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	m["thread_user"] = fmt.Sprintf("%v", int64(r.ThreadUser))
+	m["thread_system"] = fmt.Sprintf("%v", int64(r.ThreadSystem))
+	m["process_user"] = fmt.Sprintf("%v", int64(r.ProcessUser))
+	m["process_system"] = fmt.Sprintf("%v", int64(r.ProcessSystem))
+	m["nvcsw"] = fmt.Sprintf("%v", r.Nvcsw)
+	m["nivcsw"] = fmt.Sprintf("%v", r.Nivcsw)
+	m["minflt"] = fmt.Sprintf("%v", r.Minflt)
+	m["majflt"] = fmt.Sprintf("%v", r.Majflt)
+	m["maxrss_delta"] = fmt.Sprintf("%v", r.MaxRSSDelta)
+	return m
+}
+
+func (r *Rusage) fromMap(m map[string]interface{}) error {
+	for k, p := range map[string]*int64{
+		"thread_user":    (*int64)(&r.ThreadUser),
+		"thread_system":  (*int64)(&r.ThreadSystem),
+		"process_user":   (*int64)(&r.ProcessUser),
+		"process_system": (*int64)(&r.ProcessSystem),
+		"nvcsw":          &r.Nvcsw,
+		"nivcsw":         &r.Nivcsw,
+		"minflt":         &r.Minflt,
+		"majflt":         &r.Majflt,
+		"maxrss_delta":   &r.MaxRSSDelta,
+	} {
+		if v, err := strconv.ParseInt(m[k].(string), 10, 64); err != nil {
+			return err
+		} else {
+			*p = v
+		}
+	}
+	return nil
+}
+
+func (md *Metadata) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range md.strings() {
+		m[k] = *v
+	}
+	m["gomaxprocs"] = fmt.Sprintf("%v", md.GOMAXPROCS)
+	m["num_cpu"] = fmt.Sprintf("%v", md.NumCPU)
+	m["vcs_dirty"] = strconv.FormatBool(md.VCSDirty)
+	m["started_at"] = md.StartedAt.Format(time.RFC3339Nano)
+	if md.Deps != nil {
+		m["deps"] = md.Deps
+	}
+	if md.Args != nil {
+		m["args"] = md.Args
+	}
+	if md.Config != nil {
+		m["config"] = md.Config
+	}
+	return m
+}
+
+func (md *Metadata) strings() map[string]*string {
+	return map[string]*string{
+		"go_version":     &md.GoVersion,
+		"goos":           &md.GOOS,
+		"goarch":         &md.GOARCH,
+		"gogc":           &md.GOGC,
+		"godebug":        &md.GODEBUG,
+		"gomemlimit":     &md.GOMEMLIMIT,
+		"cpu_model":      &md.CPUModel,
+		"governor":       &md.Governor,
+		"hostname":       &md.Hostname,
+		"module":         &md.Module,
+		"module_version": &md.ModuleVersion,
+		"vcs_revision":   &md.VCSRevision,
+	}
+}
+
+func (md *Metadata) fromMap(m map[string]interface{}) (err error) {
+	for k, p := range md.strings() {
+		if v, ok := m[k]; ok {
+			*p = v.(string)
+		}
+	}
+	if md.GOMAXPROCS, err = strconv.Atoi(m["gomaxprocs"].(string)); err != nil {
+		return
+	}
+	if md.NumCPU, err = strconv.Atoi(m["num_cpu"].(string)); err != nil {
+		return
+	}
+	if md.VCSDirty, err = strconv.ParseBool(m["vcs_dirty"].(string)); err != nil {
+		return
+	}
+	if md.StartedAt, err = time.Parse(time.RFC3339Nano, m["started_at"].(string)); err != nil {
+		return
+	}
+	if v, ok := m["deps"]; ok {
+		md.Deps = make(map[string]string)
+		for k, x := range v.(map[string]interface{}) {
+			md.Deps[k] = x.(string)
+		}
+	}
+	if v, ok := m["args"]; ok {
+		for _, x := range v.([]interface{}) {
+			md.Args = append(md.Args, x.(string))
+		}
+	}
+	if v, ok := m["config"]; ok {
+		md.Config = make(map[string]string)
+		for k, x := range v.(map[string]interface{}) {
+			md.Config[k] = x.(string)
+		}
+	}
+	return
+}
+
+func (s *TraceSummary) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	m["running"] = fmt.Sprintf("%v", int64(s.Running))
+	m["runnable"] = fmt.Sprintf("%v", int64(s.Runnable))
+	m["blocked"] = fmt.Sprintf("%v", int64(s.Blocked))
+	m["syscall"] = fmt.Sprintf("%v", int64(s.Syscall))
+	m["gc_assist"] = fmt.Sprintf("%v", int64(s.GCAssist))
+	return m
+}
+
+func (s *TraceSummary) fromMap(m map[string]interface{}) error {
+	for k, p := range map[string]*int64{
+		"running":   (*int64)(&s.Running),
+		"runnable":  (*int64)(&s.Runnable),
+		"blocked":   (*int64)(&s.Blocked),
+		"syscall":   (*int64)(&s.Syscall),
+		"gc_assist": (*int64)(&s.GCAssist),
+	} {
+		if v, err := strconv.ParseInt(m[k].(string), 10, 64); err != nil {
+			return err
+		} else {
+			*p = v
+		}
+	}
+	return nil
+}
+
+func (rm *RuntimeMetric) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	m["cumulative"] = strconv.FormatBool(rm.Cumulative)
+	if rm.Buckets != nil {
+		buckets := make([]interface{}, len(rm.Buckets))
+		for i, b := range rm.Buckets {
+			v := make(map[string]string)
+			v["lo"] = strconv.FormatFloat(b.Lo, 'g', -1, 64)
+			v["hi"] = strconv.FormatFloat(b.Hi, 'g', -1, 64)
+			v["count"] = fmt.Sprintf("%v", b.Count)
+			buckets[i] = v
+		}
+		m["buckets"] = buckets
+	} else {
+		m["value"] = strconv.FormatFloat(rm.Value, 'g', -1, 64)
+	}
+	return m
+}
+
+func (rm *RuntimeMetric) fromMap(m map[string]interface{}) (err error) {
+	if rm.Cumulative, err = strconv.ParseBool(m["cumulative"].(string)); err != nil {
+		return
+	}
+	if v, ok := m["value"]; ok {
+		if rm.Value, err = strconv.ParseFloat(v.(string), 64); err != nil {
+			return
+		}
+	}
+	if v, ok := m["buckets"]; ok {
+		rm.Buckets = make([]Bucket, 0)
+		for _, x := range v.([]interface{}) {
+			y := x.(map[string]interface{})
+			b := Bucket{}
+			if b.Lo, err = strconv.ParseFloat(y["lo"].(string), 64); err != nil {
+				return
+			}
+			if b.Hi, err = strconv.ParseFloat(y["hi"].(string), 64); err != nil {
+				return
+			}
+			if b.Count, err = strconv.ParseUint(y["count"].(string), 10, 64); err != nil {
+				return
+			}
+			rm.Buckets = append(rm.Buckets, b)
+		}
+	}
+	return
+}
+
 func (t *T) MarshalJSON() ([]byte, error) {
 	return json.Marshal(t.toMap())
 }
@@ -110,6 +511,20 @@ func (b *Benchmark) fromMap(m map[string]interface{}) error {
 			b.Pprof.PushBack(p0)
 		}
 	}
+	if v, ok := m["affinity"]; ok {
+		if err := (*cpuList)(&b.Affinity).Set(v.(string)); err != nil {
+			return err
+		}
+	}
+	if v, ok := m["metadata"]; ok {
+		b.Metadata = &Metadata{}
+		if err := b.Metadata.fromMap(v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+	if v, ok := m["trace_file"]; ok {
+		b.TraceFile = v.(string)
+	}
 	return nil
 }
 
@@ -120,6 +535,16 @@ func (t *T) fromMap(m map[string]interface{}) error {
 		t.Err = errors.New(e.(string))
 	}
 
+	if s, ok := m["status"]; ok {
+		if err := t.fromStatus(s.(string)); err != nil {
+			return err
+		}
+	}
+
+	if s, ok := m["stack"]; ok {
+		t.Stack = s.(string)
+	}
+
 	if v, err := strconv.ParseInt(m["count"].(string), 10, 64); err != nil {
 		return err
 	} else {
@@ -135,6 +560,36 @@ func (t *T) fromMap(m map[string]interface{}) error {
 	} else {
 		t.Total = time.Duration(v)
 	}
+	if p, ok := m["procs"]; ok {
+		if v, err := strconv.ParseInt(p.(string), 10, 64); err != nil {
+			return err
+		} else {
+			t.Procs = int(v)
+		}
+	}
+	if p, ok := m["params"]; ok {
+		t.Params = make(map[string]interface{})
+		for name, v := range p.(map[string]interface{}) {
+			t.Params[name] = v.(string)
+		}
+	}
+	if p, ok := m["timeout"]; ok {
+		if v, err := strconv.ParseInt(p.(string), 10, 64); err != nil {
+			return err
+		} else {
+			t.Timeout = time.Duration(v)
+		}
+	}
+	if _, ok := m["timedout"]; ok {
+		t.TimedOut = true
+	}
+	if p, ok := m["paused"]; ok {
+		if v, err := strconv.ParseInt(p.(string), 10, 64); err != nil {
+			return err
+		} else {
+			t.Paused = time.Duration(v)
+		}
+	}
 
 	t.Children = list.New()
 	if v, ok := m["children"]; ok {
@@ -165,6 +620,95 @@ func (t *T) fromMap(m map[string]interface{}) error {
 		t.Heap = p0
 	}
 
+	if v, ok := m["heap_inuse"]; ok {
+		y := v.(map[string]interface{})
+		p0 := &ppf.Report{}
+		p0.FromMap(y)
+		t.HeapInuse = p0
+	}
+
+	if v, ok := m["cpu"]; ok {
+		y := v.(map[string]interface{})
+		p0 := &ppf.Report{}
+		p0.FromMap(y)
+		t.Cpu = p0
+	}
+
+	if v, ok := m["artifacts"]; ok {
+		for name, x := range v.(map[string]interface{}) {
+			if data, err := base64.StdEncoding.DecodeString(x.(string)); err != nil {
+				return err
+			} else {
+				t.AddArtifact(name, data)
+			}
+		}
+	}
+
+	if v, ok := m["stats"]; ok {
+		t.Stats = &Stats{}
+		if err := t.Stats.fromMap(v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := m["mem"]; ok {
+		t.Mem = &MemStats{}
+		if err := t.Mem.fromMap(v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := m["rusage"]; ok {
+		t.Rusage = &Rusage{}
+		if err := t.Rusage.fromMap(v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := m["trace"]; ok {
+		t.Trace = &TraceSummary{}
+		if err := t.Trace.fromMap(v.(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+
+	if v, ok := m["runtime"]; ok {
+		t.Runtime = make(map[string]*RuntimeMetric)
+		for name, x := range v.(map[string]interface{}) {
+			rm := &RuntimeMetric{}
+			if err := rm.fromMap(x.(map[string]interface{})); err != nil {
+				return err
+			}
+			t.Runtime[name] = rm
+		}
+	}
+
+	if b, ok := m["bytes"]; ok {
+		if v, err := strconv.ParseInt(b.(string), 10, 64); err != nil {
+			return err
+		} else {
+			t.Bytes = v
+		}
+	}
+
+	if p, ok := m["processed"]; ok {
+		if v, err := strconv.ParseInt(p.(string), 10, 64); err != nil {
+			return err
+		} else {
+			t.Processed = v
+		}
+	}
+
+	if v, ok := m["metrics"]; ok {
+		for unit, x := range v.(map[string]interface{}) {
+			if f, err := strconv.ParseFloat(x.(string), 64); err != nil {
+				return err
+			} else {
+				t.ReportMetric(f, unit)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -184,6 +728,15 @@ func (b *Benchmark) WriteJson(wr io.Writer) (int, error) {
 	}
 }
 
+// writePartialJson writes the result of the run aborted by the watchdog
+func (b *Benchmark) writePartialJson(wr io.Writer) (int, error) {
+	if bs, err := json.MarshalIndent(b.extendMap(b.T.partialMap()), "", "\t"); err != nil {
+		return 0, err
+	} else {
+		return wr.Write(bs)
+	}
+}
+
 func (b *Benchmark) ReadJson(rd io.Reader) error {
 	if bs, err := ioutil.ReadAll(rd); err != nil {
 		return err
@@ -5,7 +5,12 @@ import (
 	"bytes"
 	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type F struct {
@@ -22,6 +27,222 @@ var funcs = []*F{
 		t.Error("shit happens")
 		return nil
 	}},
+	&F{"ItLoops", func(t *T) error {
+		x := 0
+		for t.Loop() {
+			x++
+		}
+		if x != t.Count {
+			return fmt.Errorf("loop count %v is not matched %v", x, t.Count)
+		}
+		return nil
+	}},
+	&F{"ItPauses", func(t *T) error {
+		for i := 0; i < 3; i++ {
+			t.Start()
+			t.Pause()
+			time.Sleep(10 * time.Millisecond)
+			t.Resume()
+		}
+		if t.paused() < 30*time.Millisecond {
+			return fmt.Errorf("paused time %v is too small", t.paused())
+		}
+		return nil
+	}},
+	&F{"ItAllocates", func(t *T) error {
+		var a [][]byte
+		for i := 0; i < 100; i++ {
+			t.Start()
+			a = append(a, make([]byte, 1024))
+		}
+		if len(a) != t.Count {
+			return errors.New("count is not matched")
+		}
+		return nil
+	}},
+	&F{"ItReportsMetrics", func(t *T) error {
+		for i := 0; i < 2; i++ {
+			t.Run(fmt.Sprintf("ItReports%d", i), func(t *T) error {
+				t.SetBytes(4096)
+				for i := 0; i < 10; i++ {
+					t.Start()
+				}
+				t.ReportMetric(3, "rows/op")
+				t.ReportMetric(0.25, "cache-hits")
+				return nil
+			})
+		}
+		for e := t.Children.Front(); e != nil; e = e.Next() {
+			c := e.Value.(*T)
+			if c.Processed != 40960 || c.MBPerSec() <= 0 {
+				return fmt.Errorf("throughput is not matched %v %v", c.Processed, c.MBPerSec())
+			}
+			if c.Metrics["rows/op"] != 3 || c.Metrics["cache-hits"] != 0.25 {
+				return fmt.Errorf("metrics are not matched %v", c.Metrics)
+			}
+		}
+		return nil
+	}},
+	&F{"ItRunsParallel", func(t *T) error {
+		var x int64
+		t.SetParallelism(2)
+		t.RunParallel(func(pb *PB) {
+			for pb.Next() {
+				atomic.AddInt64(&x, 1)
+			}
+		})
+		if int(x) != t.Count {
+			return fmt.Errorf("parallel count %v is not matched %v", x, t.Count)
+		}
+		return nil
+	}},
+	&F{"ItSweepsProcs", func(t *T) error {
+		return t.RunCPU("ItRuns", []int{1, 2}, func(t *T) error {
+			if runtime.GOMAXPROCS(0) != t.Procs {
+				return fmt.Errorf("GOMAXPROCS is not matched %v", t.Procs)
+			}
+			return nil
+		})
+	}},
+	&F{"ItTimesOut", func(t *T) error {
+		err := t.RunTimeout("ItLoopsForever", 10*time.Millisecond, func(t *T) error {
+			for t.Loop() {
+			}
+			return t.Context().Err()
+		})
+		if _, ok := err.(*TimeoutError); !ok {
+			return fmt.Errorf("timeout is expected, got %v", err)
+		}
+		err = t.RunTimeout("ItStartsForever", 10*time.Millisecond, func(t *T) error {
+			for {
+				t.Start()
+			}
+		})
+		if _, ok := err.(*TimeoutError); !ok {
+			return fmt.Errorf("timeout is expected, got %v", err)
+		}
+		err = t.RunTimeout("ItPanicsLate", 10*time.Millisecond, func(t *T) error {
+			time.Sleep(20 * time.Millisecond)
+			panic("it panics after timeout")
+		})
+		if _, ok := err.(*PanicError); !ok || !t.Children.Back().Value.(*T).TimedOut {
+			return fmt.Errorf("panic is expected, got %v", err)
+		}
+		return nil
+	}},
+	&F{"ItPanics", func(t *T) error {
+		t.Run("ItPanicsInside", func(t *T) error {
+			panic("it panics always")
+		})
+		t.Run("ItRunsAfterPanic", func(t *T) error { return nil })
+		if t.Children.Len() != 2 {
+			return errors.New("children are not executed")
+		}
+		if t.Children.Front().Value.(*T).Stack == "" {
+			return errors.New("stack is not recorded")
+		}
+		return nil
+	}},
+	&F{"ItSkipsAndFails", func(t *T) error {
+		t.Run("ItSkips", func(t *T) error {
+			t.Skip("it skips always")
+			return errors.New("unreachable")
+		})
+		if t.Failed() {
+			return errors.New("skipped task fails parent")
+		}
+		t.Run("ItFailsNow", func(t *T) error {
+			t.Fatal("it fails now")
+			return errors.New("unreachable")
+		})
+		if !t.Failed() {
+			return errors.New("failed task does not fail parent")
+		}
+		c := t.Children.Front().Value.(*T)
+		if !c.Skipped() || c.Err != nil {
+			return errors.New("task is not skipped")
+		}
+		c = t.Children.Back().Value.(*T)
+		if !c.Failed() || c.Err != nil {
+			return errors.New("task is not failed")
+		}
+		t.Run("ItFailsAndSkips", func(t *T) error {
+			t.Error("it fails")
+			t.Skip("it skips after failure")
+			return nil
+		})
+		if c = t.Children.Back().Value.(*T); c.status() != "failed" {
+			return fmt.Errorf("failure is hidden by %s status", c.status())
+		}
+		return nil
+	}},
+	&F{"ItHooks", func(t *T) error {
+		var dir string
+		calls := 0
+		t.BeforeEach(func(t *T) error {
+			calls++
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		t.AfterEach(func(t *T) error {
+			calls++
+			return nil
+		})
+		t.Run("ItUsesTempDir", func(t *T) error {
+			dir = t.TempDir()
+			t.Cleanup(func() { calls++ })
+			t.Start()
+			return nil
+		})
+		if calls != 3 {
+			return fmt.Errorf("hooks calls count %v is not matched", calls)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			return errors.New("temp dir is not removed")
+		}
+		c := t.Children.Front().Value.(*T)
+		if c.Active >= 10*time.Millisecond || c.Total < 10*time.Millisecond {
+			return fmt.Errorf("hooks time is not excluded from active %v/%v", c.Active, c.Total)
+		}
+		return nil
+	}},
+	&F{"ItRunsMatrix", func(t *T) error {
+		axes := []Axis{
+			{"size", []interface{}{1024, 1 << 20}},
+			{"codec", []interface{}{"a", "b", "c"}},
+		}
+		err := t.RunMatrix(axes, func(t *T) error {
+			label := fmt.Sprintf("size=%v/codec=%v", t.Param("size"), t.Param("codec"))
+			if label != t.Label {
+				return fmt.Errorf("params %v are not matched label %s", t.Params, t.Label)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if t.Children.Len() != 6 {
+			return fmt.Errorf("children count %v is not matched", t.Children.Len())
+		}
+		return nil
+	}},
+	&F{"ItKeepsSamples", func(t *T) error {
+		t.KeepSamples()
+		for i := 0; i < 10; i++ {
+			t.Start()
+			time.Sleep(time.Millisecond)
+		}
+		// the last iteration is closed when the task completes
+		if len(t.Samples) != 9 {
+			return fmt.Errorf("samples count %v is not matched", len(t.Samples))
+		}
+		for _, d := range t.Samples {
+			if d < time.Millisecond {
+				return fmt.Errorf("sample %v is too small", d)
+			}
+		}
+		return nil
+	}},
 }
 
 func min(x, y int) int {
@@ -33,8 +254,11 @@ func min(x, y int) int {
 
 func Test1(t *testing.T) {
 
-	flag.Parse()
-	flag.Lookup("pprof").Value.Set("true")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	if err := fs.Parse([]string{"-pprof", "-benchtime=100ms"}); err != nil {
+		t.Fatal(err)
+	}
 
 	t0 := Run(".", func(t1 *T) error {
 		for _, f := range funcs {
@@ -43,6 +267,17 @@ func Test1(t *testing.T) {
 		return nil
 	})
 
+	for e := t0.Children.Front(); e != nil; e = e.Next() {
+		if c := e.Value.(*T); c.Label != "ItFails" && c.Err != nil {
+			t.Errorf("%s: %v", c.Label, c.Err)
+		}
+		if c := e.Value.(*T); c.Label == "ItReportsMetrics" {
+			if c.Processed != 2*40960 || c.MBPerSec() <= 0 || c.Metrics != nil {
+				t.Errorf("metrics are not rolled up %v %v %v", c.Processed, c.MBPerSec(), c.Metrics)
+			}
+		}
+	}
+
 	bf := &bytes.Buffer{}
 	wr := bufio.NewWriter(bf)
 	t0.WriteJson(wr)
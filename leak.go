@@ -0,0 +1,64 @@
+package benchmark
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// goroutines returns stacks of all live goroutines by goroutine id
+func goroutines() map[string]string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	m := make(map[string]string)
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if f := strings.Fields(g); len(f) > 1 && f[0] == "goroutine" {
+			m[f[1]] = g
+		}
+	}
+	return m
+}
+
+// checkLeaks waits -leakgrace for goroutines started by the task
+// to finish, goroutines still alive fail the task, goroutines already
+// reported by children are skipped as they are leaked by the children
+func (t *T) checkLeaks(before map[string]string) {
+	deadline := time.Now().Add(t.config().LeakGrace)
+	for {
+		leaked := make(map[string]string)
+		for id, g := range goroutines() {
+			if _, ok := before[id]; !ok && !t.leaked[id] {
+				leaked[id] = g
+			}
+		}
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			stacks := make([]string, 0, len(leaked))
+			for id, g := range leaked {
+				t.reportLeak(id)
+				stacks = append(stacks, g)
+			}
+			sort.Strings(stacks)
+			t.Errorf("%d goroutines leaked:\n\n%s", len(stacks), strings.Join(stacks, "\n\n"))
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (t *T) reportLeak(id string) {
+	if t.leaked == nil {
+		t.leaked = make(map[string]bool)
+	}
+	t.leaked[id] = true
+}
@@ -0,0 +1,51 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunnerLeakCheck(t *testing.T) {
+	c := DefaultConfig()
+	c.LeakCheck = true
+	c.LeakGrace = 20 * time.Millisecond
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		t.Run("ItLeaks", func(t *T) error {
+			go func() { <-stop }()
+			return nil
+		})
+		t.Run("ItLeaksInside", func(t *T) error {
+			return t.Run("ItLeaks", func(t *T) error {
+				go func() { <-stop }()
+				return nil
+			})
+		})
+		t.Run("ItWaits", func(t *T) error {
+			done := make(chan struct{})
+			go func() {
+				time.Sleep(5 * time.Millisecond)
+				close(done)
+			}()
+			return nil
+		})
+		return nil
+	})
+
+	if c := b.Children.Front().Value.(*T); !c.Failed() {
+		t.Error("leaked goroutine is not detected")
+	}
+	if c := b.Children.Back().Value.(*T); c.Failed() {
+		t.Error("finished goroutine is reported as leaked")
+	}
+	c0 := b.Children.Front().Next().Value.(*T)
+	if c0.Messages.Len() != 0 || c0.Children.Front().Value.(*T).Messages.Len() != 1 {
+		t.Error("leaked goroutine is reported not only by the task leaking it")
+	}
+	if b.Messages.Len() != 0 {
+		t.Error("leaked goroutine is reported by the root")
+	}
+}
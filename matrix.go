@@ -0,0 +1,53 @@
+package benchmark
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Axis struct {
+	Name   string
+	Values []interface{}
+}
+
+// RunMatrix runs f for every combination of axes values, children are
+// labelled as name=value/name=value and get the values in Params,
+// the result keeps values formatted by fmt.Sprint so they are read back as strings
+func (t *T) RunMatrix(axes []Axis, f func(*T) error) (err error) {
+	for _, a := range axes {
+		if len(a.Values) == 0 {
+			return nil
+		}
+	}
+
+	idx := make([]int, len(axes))
+	for {
+		params := make(map[string]interface{}, len(axes))
+		labels := make([]string, len(axes))
+		for i, a := range axes {
+			params[a.Name] = a.Values[idx[i]]
+			labels[i] = fmt.Sprintf("%s=%v", a.Name, a.Values[idx[i]])
+		}
+
+		t0 := t.newChild(strings.Join(labels, "/"))
+		t0.Params = params
+		if e := t.runChild(t0, f); err == nil {
+			err = e
+		}
+
+		i := len(axes) - 1
+		for ; i >= 0; i-- {
+			if idx[i]++; idx[i] < len(axes[i].Values) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return
+		}
+	}
+}
+
+func (t *T) Param(name string) interface{} {
+	return t.Params[name]
+}
@@ -0,0 +1,26 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParamsJson(t *testing.T) {
+	b := NewRunner(DefaultConfig()).Run(".", func(t *T) error {
+		return t.RunMatrix([]Axis{{"size", []interface{}{int64(1) << 60}}, {"fn", []interface{}{func() {}}}}, func(t *T) error {
+			return nil
+		})
+	})
+
+	bs, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b0 := &Benchmark{}
+	if err := json.Unmarshal(bs, b0); err != nil {
+		t.Fatal(err)
+	}
+	if v := b0.Children.Front().Value.(*T).Param("size"); v != "1152921504606846976" {
+		t.Errorf("param is not matched %v", v)
+	}
+}
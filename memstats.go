@@ -0,0 +1,45 @@
+package benchmark
+
+import "runtime"
+
+type MemStats struct {
+	Mallocs, Frees, Bytes   uint64
+	AllocsPerOp, BytesPerOp uint64
+}
+
+type memCounter struct {
+	mallocs, frees, bytes uint64
+}
+
+func readMemCounter() memCounter {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return memCounter{ms.Mallocs, ms.Frees, ms.TotalAlloc}
+}
+
+func (c *memCounter) add(x memCounter) {
+	c.mallocs += x.mallocs
+	c.frees += x.frees
+	c.bytes += x.bytes
+}
+
+func (c memCounter) since(start memCounter) memCounter {
+	return memCounter{c.mallocs - start.mallocs, c.frees - start.frees, c.bytes - start.bytes}
+}
+
+func (c memCounter) stats(count int) *MemStats {
+	ms := &MemStats{Mallocs: c.mallocs, Frees: c.frees, Bytes: c.bytes}
+	if count > 0 {
+		ms.AllocsPerOp = c.mallocs / uint64(count)
+		ms.BytesPerOp = c.bytes / uint64(count)
+	}
+	return ms
+}
+
+func (t *T) startMem() {
+	t.memStart = readMemCounter()
+}
+
+func (t *T) stopMem() {
+	t.memNet.add(readMemCounter().since(t.memStart))
+}
@@ -0,0 +1,20 @@
+package benchmark
+
+import "testing"
+
+func TestMemNoOverhead(t *testing.T) {
+	b := NewRunner(DefaultConfig()).Run(".", func(t *T) error {
+		return t.Run("ItPauses", func(t *T) error {
+			for i := 0; i < 1000; i++ {
+				t.Start()
+				t.Pause()
+				t.Resume()
+			}
+			return nil
+		})
+	})
+
+	if m := b.Children.Front().Value.(*T).Mem; m == nil || m.Mallocs != 0 {
+		t.Errorf("harness allocations are counted %+v", m)
+	}
+}
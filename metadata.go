@@ -0,0 +1,99 @@
+package benchmark
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Metadata describes environment and build of the benchmarking run
+type Metadata struct {
+	GoVersion, GOOS, GOARCH   string
+	GOMAXPROCS                int
+	GOGC, GODEBUG, GOMEMLIMIT string
+	CPUModel                  string
+	NumCPU                    int
+	Governor                  string
+	Hostname                  string
+	Module, ModuleVersion     string
+	Deps                      map[string]string
+	VCSRevision               string
+	VCSDirty                  bool
+	Args                      []string
+	Config                    map[string]string
+	StartedAt                 time.Time
+}
+
+func newMetadata(c *Config) *Metadata {
+	md := &Metadata{
+		GoVersion:  runtime.Version(),
+		GOOS:       runtime.GOOS,
+		GOARCH:     runtime.GOARCH,
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		GOGC:       os.Getenv("GOGC"),
+		GODEBUG:    os.Getenv("GODEBUG"),
+		GOMEMLIMIT: os.Getenv("GOMEMLIMIT"),
+		Args:       os.Args,
+		Config:     c.flags(),
+		StartedAt:  time.Now(),
+	}
+	md.Hostname, _ = os.Hostname()
+	md.readCpuInfo()
+	if b, err := ioutil.ReadFile("/sys/devices/system/cpu/cpu0/cpufreq/scaling_governor"); err == nil {
+		md.Governor = strings.TrimSpace(string(b))
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		md.Module = bi.Main.Path
+		md.ModuleVersion = bi.Main.Version
+		md.Deps = make(map[string]string)
+		for _, d := range bi.Deps {
+			if d.Replace != nil {
+				d = d.Replace
+			}
+			md.Deps[d.Path] = d.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				md.VCSRevision = s.Value
+			case "vcs.modified":
+				md.VCSDirty = s.Value == "true"
+			}
+		}
+	}
+	return md
+}
+
+// readCpuInfo takes the model of the first processor and counts processors,
+// NumCPU falls back to runtime.NumCPU if /proc/cpuinfo is not readable
+func (md *Metadata) readCpuInfo() {
+	md.NumCPU = runtime.NumCPU()
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	n := 0
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		kv := strings.SplitN(sc.Text(), ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "processor":
+			n++
+		case "model name":
+			if md.CPUModel == "" {
+				md.CPUModel = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	if n != 0 {
+		md.NumCPU = n
+	}
+}
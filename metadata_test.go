@@ -0,0 +1,41 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	c := DefaultConfig()
+	c.BenchTime = 100 * time.Millisecond
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return nil
+	})
+
+	md := b.Metadata
+	if md == nil || md.GoVersion != runtime.Version() || md.NumCPU <= 0 || md.StartedAt.IsZero() {
+		t.Fatalf("metadata is not captured %+v", md)
+	}
+	if v := md.Config["benchtime"]; v != "100ms" {
+		t.Errorf("effective benchtime %q is not recorded", v)
+	}
+
+	bs, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b0 := &Benchmark{}
+	if err := json.Unmarshal(bs, b0); err != nil {
+		t.Fatal(err)
+	}
+	if !b0.Metadata.StartedAt.Equal(md.StartedAt) {
+		t.Errorf("start timestamp is not unmarshaled %v", b0.Metadata.StartedAt)
+	}
+	b0.Metadata.StartedAt = md.StartedAt
+	if !reflect.DeepEqual(b0.Metadata, md) {
+		t.Errorf("metadata is not unmarshaled %+v != %+v", *b0.Metadata, *md)
+	}
+}
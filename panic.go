@@ -0,0 +1,36 @@
+package benchmark
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func recovered(r interface{}) *PanicError {
+	if e, ok := r.(*PanicError); ok {
+		return e
+	}
+	return &PanicError{r, string(debug.Stack())}
+}
+
+func (t *T) call(f func(*T) error) (err error) {
+	defer func() {
+		// errAbort is raised by FailNow and SkipNow to unwind the task
+		if r := recover(); r != nil && r != errAbort {
+			e := recovered(r)
+			err = e
+			if t.Stack == "" {
+				t.Stack = e.Stack
+			}
+		}
+	}()
+	return f(t)
+}
@@ -0,0 +1,100 @@
+package benchmark
+
+import (
+	"runtime"
+	"runtime/trace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type PB struct {
+	count    *int64
+	done     <-chan struct{}
+	deadline time.Time
+	grain    int64
+	cache    int64
+	grabbed  time.Time
+}
+
+const maxGrain = 1e5
+
+// Next reports whether there are more iterations to execute,
+// iterations are taken from the shared counter by batches
+func (pb *PB) Next() bool {
+	if pb.cache > 0 {
+		pb.cache--
+		return true
+	}
+
+	now := time.Now()
+	if !now.Before(pb.deadline) {
+		return false
+	}
+
+	select {
+	case <-pb.done:
+		return false
+	default:
+	}
+
+	if pb.grabbed != (time.Time{}) && now.Sub(pb.grabbed) < 100*time.Microsecond && pb.grain < maxGrain {
+		pb.grain *= 2
+	}
+	pb.grabbed = now
+
+	atomic.AddInt64(pb.count, pb.grain)
+	pb.cache = pb.grain - 1
+	return true
+}
+
+// SetParallelism sets the number of goroutines used by RunParallel to p*GOMAXPROCS
+func (t *T) SetParallelism(p int) {
+	if p >= 1 {
+		t.parallelism = p
+	}
+}
+
+// RunParallel runs the body in parallel goroutines for -benchtime,
+// iterations executed by all goroutines are added to Count
+func (t *T) RunParallel(body func(*PB)) {
+	p := t.parallelism
+	if p < 1 {
+		p = 1
+	}
+	n := p * runtime.GOMAXPROCS(0)
+
+	t.start()
+
+	var count int64
+	deadline := time.Now().Add(t.config().BenchTime)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var pe interface{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					once.Do(func() {
+						if pe = r; r != errAbort {
+							pe = recovered(r)
+						}
+					})
+				}
+			}()
+			t.setActiveLabels()
+			defer trace.StartRegion(t.Context(), t.Path()).End()
+			defer trace.StartRegion(t.Context(), "active").End()
+			body(&PB{count: &count, done: t.Context().Done(), deadline: deadline, grain: 1})
+		}()
+	}
+	wg.Wait()
+
+	t.Count += int(count)
+	if pe != nil {
+		panic(pe)
+	}
+}
@@ -0,0 +1,31 @@
+package ppftool
+
+import (
+	"bytes"
+
+	"github.com/google/pprof/profile"
+)
+
+// Diff returns profile b with samples of base subtracted
+func Diff(base, b []byte) ([]byte, error) {
+	p0, err := profile.ParseData(base)
+	if err != nil {
+		return nil, err
+	}
+	p1, err := profile.ParseData(b)
+	if err != nil {
+		return nil, err
+	}
+
+	p0.Scale(-1)
+	p, err := profile.Merge([]*profile.Profile{p1, p0})
+	if err != nil {
+		return nil, err
+	}
+
+	bf := &bytes.Buffer{}
+	if err := p.Write(bf); err != nil {
+		return nil, err
+	}
+	return bf.Bytes(), nil
+}
@@ -35,24 +35,32 @@ const (
 	AllocSpaceIndex
 	InuseObjectsIndex
 	InuseSpaceIndex
+	ContentionsIndex
+	DelayIndex
 )
 
 func (o *Options) unit() Unit {
 	if o.Unit == DefaultUnit {
 		switch o.Index {
-		case AllocObjectsIndex, InuseObjectsIndex:
+		case AllocObjectsIndex, InuseObjectsIndex, ContentionsIndex:
 			return None
 		case AllocSpaceIndex, InuseSpaceIndex:
 			return Megabyte
+		case DelayIndex:
+			return Millisecond
 		}
 	}
 	return o.Unit
 }
 
 func (o *Options) flagset(c ...string) driver.FlagSet {
-	if o.Count > 0 {
-		c = append(c, fmt.Sprintf("-nodecount=%d", o.Count))
+	// pprof driver keeps options between calls,
+	// so all of them are passed even empty
+	count := o.Count
+	if count <= 0 {
+		count = -1
 	}
+	c = append(c, fmt.Sprintf("-nodecount=%d", count))
 
 	if o.CumSort {
 		c = append(c, "-cum=true")
@@ -60,30 +68,16 @@ func (o *Options) flagset(c ...string) driver.FlagSet {
 		c = append(c, "-flat=true")
 	}
 
-	if len(o.Hide) > 0 {
-		c = append(c, "-hide="+strings.Join(o.Hide, "|"))
-	}
-	if len(o.Show) > 0 {
-		c = append(c, "-show="+strings.Join(o.Show, "|"))
-	}
-	if len(o.Ignore) > 0 {
-		c = append(c, "-ignore="+strings.Join(o.Ignore, "|"))
-	}
-	if len(o.Focus) > 0 {
-		c = append(c, "-focus="+strings.Join(o.Focus, "|"))
-	}
-	if len(o.TagHide) > 0 {
-		c = append(c, "-taghide="+strings.Join(o.TagHide, "|"))
-	}
-	if len(o.TagShow) > 0 {
-		c = append(c, "-tagshow="+strings.Join(o.TagShow, "|"))
-	}
-	if len(o.TagIgnore) > 0 {
-		c = append(c, "-tagignore="+strings.Join(o.TagIgnore, "|"))
-	}
-	if len(o.TagFocus) != 0 {
-		c = append(c, "-tagfocus="+strings.Join(o.TagFocus, "|"))
-	}
+	c = append(c,
+		"-hide="+strings.Join(o.Hide, "|"),
+		"-show="+strings.Join(o.Show, "|"),
+		"-ignore="+strings.Join(o.Ignore, "|"),
+		"-focus="+strings.Join(o.Focus, "|"),
+		"-taghide="+strings.Join(o.TagHide, "|"),
+		"-tagshow="+strings.Join(o.TagShow, "|"),
+		"-tagignore="+strings.Join(o.TagIgnore, "|"),
+		"-tagfocus="+strings.Join(o.TagFocus, "|"),
+	)
 
 	c = append(c, "-unit="+o.unit().String())
 
@@ -96,7 +90,12 @@ func (o *Options) flagset(c ...string) driver.FlagSet {
 		c = append(c, "-sample_index=inuse_objects")
 	case InuseSpaceIndex:
 		c = append(c, "-sample_index=inuse_space")
+	case ContentionsIndex:
+		c = append(c, "-sample_index=contentions")
+	case DelayIndex:
+		c = append(c, "-sample_index=delay")
 	default:
+		c = append(c, "-sample_index=")
 	}
 
 	return Flagset(c...)
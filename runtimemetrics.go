@@ -0,0 +1,135 @@
+package benchmark
+
+import "runtime/metrics"
+
+var runtimeMetricNames = []string{
+	"/gc/cycles/total:gc-cycles",
+	"/gc/pauses:seconds",
+	"/gc/heap/goal:bytes",
+	"/gc/heap/allocs-by-size:bytes",
+	"/sched/latencies:seconds",
+	"/sched/goroutines:goroutines",
+	"/sync/mutex/wait/total:seconds",
+	"/cgo/go-to-c-calls:calls",
+}
+
+// RuntimeMetric is a delta of cumulative metric or the last value of gauge,
+// histograms keep only buckets having samples
+type RuntimeMetric struct {
+	Cumulative bool
+	Value      float64
+	Buckets    []Bucket
+}
+
+type Bucket struct {
+	Lo, Hi float64
+	Count  uint64
+}
+
+var runtimeMetricDescs = supportedRuntimeMetrics()
+
+func supportedRuntimeMetrics() map[string]metrics.Description {
+	all := make(map[string]metrics.Description)
+	for _, d := range metrics.All() {
+		all[d.Name] = d
+	}
+	m := make(map[string]metrics.Description)
+	for _, name := range runtimeMetricNames {
+		if d, ok := all[name]; ok {
+			m[name] = d
+		}
+	}
+	return m
+}
+
+func readRuntimeMetrics() []metrics.Sample {
+	samples := make([]metrics.Sample, 0, len(runtimeMetricDescs))
+	for _, name := range runtimeMetricNames {
+		if _, ok := runtimeMetricDescs[name]; ok {
+			samples = append(samples, metrics.Sample{Name: name})
+		}
+	}
+	metrics.Read(samples)
+	return samples
+}
+
+func runtimeMetricsSince(cur, start []metrics.Sample) map[string]*RuntimeMetric {
+	m := make(map[string]*RuntimeMetric, len(cur))
+	for i, s := range cur {
+		rm := &RuntimeMetric{Cumulative: runtimeMetricDescs[s.Name].Cumulative}
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			rm.Value = float64(s.Value.Uint64())
+			if rm.Cumulative {
+				rm.Value -= float64(start[i].Value.Uint64())
+			}
+		case metrics.KindFloat64:
+			rm.Value = s.Value.Float64()
+			if rm.Cumulative {
+				rm.Value -= start[i].Value.Float64()
+			}
+		case metrics.KindFloat64Histogram:
+			h, h0 := s.Value.Float64Histogram(), start[i].Value.Float64Histogram()
+			rm.Buckets = make([]Bucket, 0)
+			for j, n := range h.Counts {
+				if n -= h0.Counts[j]; n != 0 {
+					rm.Buckets = append(rm.Buckets, Bucket{h.Buckets[j], h.Buckets[j+1], n})
+				}
+			}
+		default:
+			continue
+		}
+		m[s.Name] = rm
+	}
+	return m
+}
+
+func addRuntimeMetrics(to *map[string]*RuntimeMetric, from map[string]*RuntimeMetric) {
+	if *to == nil {
+		*to = make(map[string]*RuntimeMetric, len(from))
+	}
+	for name, x := range from {
+		rm, ok := (*to)[name]
+		if !ok {
+			rm = &RuntimeMetric{Cumulative: x.Cumulative}
+			(*to)[name] = rm
+		}
+		if x.Cumulative {
+			rm.Value += x.Value
+		} else {
+			rm.Value = x.Value
+		}
+		rm.Buckets = mergeBuckets(rm.Buckets, x.Buckets)
+	}
+}
+
+func mergeBuckets(a, b []Bucket) []Bucket {
+	r := make([]Bucket, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || i < len(a) && a[i].Lo < b[j].Lo:
+			r = append(r, a[i])
+			i++
+		case i == len(a) || b[j].Lo < a[i].Lo:
+			r = append(r, b[j])
+			j++
+		default:
+			r = append(r, Bucket{a[i].Lo, a[i].Hi, a[i].Count + b[j].Count})
+			i++
+			j++
+		}
+	}
+	if a == nil && b == nil {
+		return nil
+	}
+	return r
+}
+
+func (t *T) startRuntimeMetrics() {
+	t.rtStart = readRuntimeMetrics()
+}
+
+func (t *T) stopRuntimeMetrics() {
+	addRuntimeMetrics(&t.rtNet, runtimeMetricsSince(readRuntimeMetrics(), t.rtStart))
+}
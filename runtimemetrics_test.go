@@ -0,0 +1,18 @@
+package benchmark
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeBuckets(t *testing.T) {
+	a := []Bucket{{0, 1, 1}, {2, 3, 2}}
+	b := []Bucket{{1, 2, 5}, {2, 3, 1}, {4, 5, 1}}
+	r := mergeBuckets(a, b)
+	if !reflect.DeepEqual(r, []Bucket{{0, 1, 1}, {1, 2, 5}, {2, 3, 3}, {4, 5, 1}}) {
+		t.Errorf("merged buckets are not matched %v", r)
+	}
+	if mergeBuckets(nil, nil) != nil {
+		t.Error("merged gauge must not have buckets")
+	}
+}
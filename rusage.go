@@ -0,0 +1,54 @@
+package benchmark
+
+import "time"
+
+// Rusage holds thread and process resource usage of the task,
+// context switches and page faults are counted for the task thread
+type Rusage struct {
+	ThreadUser, ThreadSystem   time.Duration
+	ProcessUser, ProcessSystem time.Duration
+
+	Nvcsw, Nivcsw, Minflt, Majflt int64
+
+	MaxRSSDelta int64 // in kilobytes
+}
+
+func (r Rusage) since(start Rusage) Rusage {
+	return Rusage{
+		ThreadUser:    r.ThreadUser - start.ThreadUser,
+		ThreadSystem:  r.ThreadSystem - start.ThreadSystem,
+		ProcessUser:   r.ProcessUser - start.ProcessUser,
+		ProcessSystem: r.ProcessSystem - start.ProcessSystem,
+		Nvcsw:         r.Nvcsw - start.Nvcsw,
+		Nivcsw:        r.Nivcsw - start.Nivcsw,
+		Minflt:        r.Minflt - start.Minflt,
+		Majflt:        r.Majflt - start.Majflt,
+		MaxRSSDelta:   r.MaxRSSDelta - start.MaxRSSDelta,
+	}
+}
+
+func (r *Rusage) add(x Rusage) {
+	r.ThreadUser += x.ThreadUser
+	r.ThreadSystem += x.ThreadSystem
+	r.ProcessUser += x.ProcessUser
+	r.ProcessSystem += x.ProcessSystem
+	r.Nvcsw += x.Nvcsw
+	r.Nivcsw += x.Nivcsw
+	r.Minflt += x.Minflt
+	r.Majflt += x.Majflt
+	if x.MaxRSSDelta > r.MaxRSSDelta {
+		r.MaxRSSDelta = x.MaxRSSDelta
+	}
+}
+
+func (t *T) startRusage() {
+	t.rusageStart, t.hasRusage = readRusage()
+}
+
+func (t *T) stopRusage() {
+	if t.hasRusage {
+		if r, ok := readRusage(); ok {
+			t.rusageNet.add(r.since(t.rusageStart))
+		}
+	}
+}
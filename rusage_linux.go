@@ -0,0 +1,28 @@
+package benchmark
+
+import (
+	"syscall"
+	"time"
+)
+
+// readRusage returns current counters, MaxRSSDelta is the absolute maxrss here
+func readRusage() (Rusage, bool) {
+	var th, pr syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_THREAD, &th) != nil {
+		return Rusage{}, false
+	}
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &pr) != nil {
+		return Rusage{}, false
+	}
+	return Rusage{
+		ThreadUser:    time.Duration(th.Utime.Nano()),
+		ThreadSystem:  time.Duration(th.Stime.Nano()),
+		ProcessUser:   time.Duration(pr.Utime.Nano()),
+		ProcessSystem: time.Duration(pr.Stime.Nano()),
+		Nvcsw:         th.Nvcsw,
+		Nivcsw:        th.Nivcsw,
+		Minflt:        th.Minflt,
+		Majflt:        th.Majflt,
+		MaxRSSDelta:   pr.Maxrss,
+	}, true
+}
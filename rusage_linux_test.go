@@ -0,0 +1,38 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRusage(t *testing.T) {
+	var sink int
+	b := NewRunner(DefaultConfig()).Run(".", func(t *T) error {
+		t.Run("ItSpins", func(t *T) error {
+			t.Start()
+			for t0 := time.Now(); time.Since(t0) < 100*time.Millisecond; {
+				sink++
+			}
+			return nil
+		})
+		return t.Run("ItSleeps", func(t *T) error {
+			t.Start()
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	})
+
+	spins, sleeps := b.Children.Front().Value.(*T).Rusage, b.Children.Back().Value.(*T).Rusage
+	if spins == nil || sleeps == nil {
+		t.Fatalf("rusage is not recorded")
+	}
+	if spins.ThreadUser < 50*time.Millisecond {
+		t.Errorf("cpu time of spinning task is too small %v", spins.ThreadUser)
+	}
+	if sleeps.ThreadUser > 20*time.Millisecond {
+		t.Errorf("cpu time of sleeping task is too large %v", sleeps.ThreadUser)
+	}
+	if sleeps.Nvcsw == 0 {
+		t.Errorf("sleeping task has no voluntary context switches")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package benchmark
+
+func readRusage() (Rusage, bool) {
+	return Rusage{}, false
+}
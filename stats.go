@@ -0,0 +1,54 @@
+package benchmark
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type Stats struct {
+	Min, Max, Mean, Stddev, Median, P90, P99 time.Duration
+}
+
+func newStats(samples []time.Duration) *Stats {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	a := make([]time.Duration, len(samples))
+	copy(a, samples)
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+
+	var sum float64
+	for _, v := range a {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(a))
+
+	var sq float64
+	for _, v := range a {
+		d := float64(v) - mean
+		sq += d * d
+	}
+
+	return &Stats{
+		Min:    a[0],
+		Max:    a[len(a)-1],
+		Mean:   time.Duration(mean),
+		Stddev: time.Duration(math.Sqrt(sq / float64(len(a)))),
+		Median: percentile(a, 0.5),
+		P90:    percentile(a, 0.9),
+		P99:    percentile(a, 0.99),
+	}
+}
+
+// percentile expects sorted samples and interpolates between the closest ranks
+func percentile(a []time.Duration, p float64) time.Duration {
+	r := p * float64(len(a)-1)
+	i := int(r)
+	if i+1 >= len(a) {
+		return a[len(a)-1]
+	}
+	f := r - float64(i)
+	return a[i] + time.Duration(f*float64(a[i+1]-a[i]))
+}
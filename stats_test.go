@@ -0,0 +1,56 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	samples := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i))
+	}
+
+	s := newStats(samples)
+	if s.Min != 1 || s.Max != 100 {
+		t.Errorf("min/max is not matched %v/%v", s.Min, s.Max)
+	}
+	if s.Mean != 50 {
+		t.Errorf("mean is not matched %v", s.Mean)
+	}
+	if s.Median != 50 {
+		t.Errorf("median is not matched %v", s.Median)
+	}
+	if s.P90 != 90 || s.P99 != 99 {
+		t.Errorf("percentiles are not matched %v/%v", s.P90, s.P99)
+	}
+	if s.Stddev != 28 {
+		t.Errorf("stddev is not matched %v", s.Stddev)
+	}
+
+	if newStats(nil) != nil {
+		t.Error("stats of empty samples must be nil")
+	}
+}
+
+func TestSamplesLimit(t *testing.T) {
+	c := DefaultConfig()
+	c.Samples = true
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItSamples", func(t *T) error {
+			for i := 0; i < 3*MaxSamples; i++ {
+				t.Start()
+			}
+			return nil
+		})
+	})
+
+	c0 := b.Children.Front().Value.(*T)
+	if len(c0.Samples) != MaxSamples {
+		t.Errorf("samples count %v is not limited", len(c0.Samples))
+	}
+	if s := c0.Stats; s == nil || s.Min > s.Median || s.Median > s.Max {
+		t.Errorf("stats are not matched %+v", s)
+	}
+}
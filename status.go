@@ -0,0 +1,86 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errAbort = errors.New("task is aborted")
+
+// FailNow marks the task as failed and stops its execution,
+// it must be called from the goroutine running the task
+func (t *T) FailNow() {
+	t.fail()
+	panic(errAbort)
+}
+
+// fail marks the task as failed, it's called from workers of RunParallel
+// and the watchdog as well as from the task goroutine
+func (t *T) fail() {
+	t.mu.Lock()
+	t.failed = true
+	t.mu.Unlock()
+}
+
+func (t *T) Fatal(a ...interface{}) {
+	t.Error(a...)
+	t.FailNow()
+}
+
+func (t *T) Fatalf(ft string, a ...interface{}) {
+	t.Errorf(ft, a...)
+	t.FailNow()
+}
+
+func (t *T) SkipNow() {
+	t.skipped = true
+	panic(errAbort)
+}
+
+func (t *T) Skip(a ...interface{}) {
+	t.message(MsgSkip, fmt.Sprint(a...))
+	t.SkipNow()
+}
+
+func (t *T) Skipf(ft string, a ...interface{}) {
+	t.message(MsgSkip, fmt.Sprintf(ft, a...))
+	t.SkipNow()
+}
+
+func (t *T) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed || t.Err != nil
+}
+
+func (t *T) Skipped() bool {
+	return t.skipped
+}
+
+// status is called with locked mutex, failure takes precedence over skipping
+func (t *T) status() string {
+	switch {
+	case t.filtered:
+		return "filtered"
+	case t.failed || t.Err != nil:
+		return "failed"
+	case t.skipped:
+		return "skipped"
+	}
+	return "ok"
+}
+
+func (t *T) fromStatus(s string) error {
+	switch s {
+	case "filtered":
+		t.filtered = true
+	case "skipped":
+		t.skipped = true
+	case "failed":
+		t.failed = true
+	case "ok":
+	default:
+		return fmt.Errorf("invalid task status %s", s)
+	}
+	return nil
+}
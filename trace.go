@@ -0,0 +1,185 @@
+package benchmark
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime/trace"
+	"strings"
+	"time"
+
+	xtrace "golang.org/x/exp/trace"
+)
+
+// tracePhase ends the region of the current phase of the leaf task
+// and starts a new one, empty phase just ends it
+func (t *T) tracePhase(phase string) {
+	if t.region != nil {
+		t.region.End()
+		t.region = nil
+	}
+	if phase != "" && trace.IsEnabled() {
+		t.region = trace.StartRegion(t.Context(), phase)
+	}
+}
+
+func (t *T) traceRegion(t0 *T) *trace.Region {
+	return trace.StartRegion(t.Context(), t0.Path())
+}
+
+// TraceSummary is time spent by goroutines of the task in its active phase
+// split by their state in the execution trace, GC assist is a part of running
+type TraceSummary struct {
+	Running, Runnable, Blocked, Syscall, GCAssist time.Duration
+}
+
+func (s *TraceSummary) add(x TraceSummary) {
+	s.Running += x.Running
+	s.Runnable += x.Runnable
+	s.Blocked += x.Blocked
+	s.Syscall += x.Syscall
+	s.GCAssist += x.GCAssist
+}
+
+func (s *TraceSummary) addState(state xtrace.GoState, reason string, d time.Duration) {
+	switch state {
+	case xtrace.GoRunning:
+		s.Running += d
+	case xtrace.GoRunnable:
+		s.Runnable += d
+	case xtrace.GoSyscall:
+		s.Syscall += d
+	case xtrace.GoWaiting:
+		// sleeping, network and GC waits are not counted
+		for _, p := range []string{"sync", "chan", "select"} {
+			if strings.HasPrefix(reason, p) {
+				s.Blocked += d
+				break
+			}
+		}
+	}
+}
+
+type traceGoroutine struct {
+	regions []string
+	state   xtrace.GoState
+	reason  string
+	since   xtrace.Time
+	assist  xtrace.Time
+}
+
+// task is the path of the task the goroutine is active in,
+// the phase region is nested into the region of the task
+func (g *traceGoroutine) task() string {
+	if n := len(g.regions); n >= 2 && g.regions[n-1] == "active" {
+		return g.regions[n-2]
+	}
+	return ""
+}
+
+func readTrace(path string) (map[string]*TraceSummary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return summarizeTrace(bufio.NewReader(f))
+}
+
+func summarizeTrace(rd io.Reader) (map[string]*TraceSummary, error) {
+	r, err := xtrace.NewReader(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	gs := make(map[xtrace.GoID]*traceGoroutine)
+	sums := make(map[string]*TraceSummary)
+
+	goroutine := func(id xtrace.GoID) *traceGoroutine {
+		g, ok := gs[id]
+		if !ok {
+			g = &traceGoroutine{}
+			gs[id] = g
+		}
+		return g
+	}
+	summary := func(path string) *TraceSummary {
+		s, ok := sums[path]
+		if !ok {
+			s = &TraceSummary{}
+			sums[path] = s
+		}
+		return s
+	}
+	flush := func(g *traceGoroutine, now xtrace.Time) {
+		if path := g.task(); path != "" && g.since != 0 {
+			summary(path).addState(g.state, g.reason, now.Sub(g.since))
+		}
+		g.since = now
+	}
+
+	for {
+		ev, err := r.ReadEvent()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch ev.Kind() {
+		case xtrace.EventStateTransition:
+			st := ev.StateTransition()
+			if st.Resource.Kind == xtrace.ResourceGoroutine {
+				g := goroutine(st.Resource.Goroutine())
+				flush(g, ev.Time())
+				_, g.state = st.Goroutine()
+				g.reason = st.Reason
+			}
+		case xtrace.EventRegionBegin:
+			g := goroutine(ev.Goroutine())
+			flush(g, ev.Time())
+			g.regions = append(g.regions, ev.Region().Type)
+		case xtrace.EventRegionEnd:
+			g := goroutine(ev.Goroutine())
+			flush(g, ev.Time())
+			if n := len(g.regions); n != 0 {
+				g.regions = g.regions[:n-1]
+			}
+		case xtrace.EventRangeBegin, xtrace.EventRangeEnd:
+			rg := ev.Range()
+			if rg.Name != "GC mark assist" || rg.Scope.Kind != xtrace.ResourceGoroutine {
+				continue
+			}
+			g := goroutine(rg.Scope.Goroutine())
+			if ev.Kind() == xtrace.EventRangeBegin {
+				g.assist = ev.Time()
+			} else if g.assist != 0 {
+				if path := g.task(); path != "" {
+					summary(path).GCAssist += ev.Time().Sub(g.assist)
+				}
+				g.assist = 0
+			}
+		}
+	}
+
+	return sums, nil
+}
+
+// setTrace assigns summaries to leaf tasks, parents get sum of children
+func (t *T) setTrace(sums map[string]*TraceSummary) *TraceSummary {
+	if t.Children.Len() == 0 {
+		t.Trace = sums[t.Path()]
+		return t.Trace
+	}
+	var s *TraceSummary
+	for e := t.Children.Front(); e != nil; e = e.Next() {
+		if x := e.Value.(*T).setTrace(sums); x != nil {
+			if s == nil {
+				s = &TraceSummary{}
+			}
+			s.add(*x)
+		}
+	}
+	t.Trace = s
+	return s
+}
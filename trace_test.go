@@ -0,0 +1,58 @@
+package benchmark
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	c := DefaultConfig()
+	c.Trace = filepath.Join(t.TempDir(), "trace.out")
+
+	b := NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItTraces", func(t *T) error {
+			t.Start()
+			t.Pause()
+			time.Sleep(time.Millisecond)
+			t.Resume()
+			spinForCpuProfile(10 * time.Millisecond)
+			c := make(chan struct{})
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				close(c)
+			}()
+			<-c
+			return nil
+		})
+	})
+
+	if b.TraceFile != c.Trace {
+		t.Errorf("trace file %q is not recorded", b.TraceFile)
+	}
+	if _, ok := b.Artifacts["trace"]; ok {
+		t.Errorf("trace is kept in the result")
+	}
+	data, err := ioutil.ReadFile(c.Trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, region := range []string{"ItTraces", "active", "paused"} {
+		if !bytes.Contains(data, []byte(region)) {
+			t.Errorf("trace has no %v region", region)
+		}
+	}
+
+	c0 := b.Children.Front().Value.(*T)
+	if c0.Trace == nil || b.Trace == nil {
+		t.Fatalf("trace is not summarized")
+	}
+	if c0.Trace.Running < 5*time.Millisecond || c0.Trace.Blocked < 5*time.Millisecond {
+		t.Errorf("trace summary does not match the task %+v", *c0.Trace)
+	}
+	if *b.Trace != *c0.Trace {
+		t.Errorf("trace summary is not aggregated %+v != %+v", *b.Trace, *c0.Trace)
+	}
+}
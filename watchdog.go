@@ -0,0 +1,95 @@
+package benchmark
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// startWatchdog arms a timer firing when the task runs longer than
+// -watchdog, while a child is running the timer is rearmed because
+// the child has its own watchdog
+func (t *T) startWatchdog() (stop func()) {
+	d := t.config().Watchdog
+	if d <= 0 {
+		return func() {}
+	}
+	var timer *time.Timer
+	var mu sync.Mutex
+	stopped := false
+	timer = time.AfterFunc(d, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		t.mu.Lock()
+		running := t.running != nil
+		t.mu.Unlock()
+		if running {
+			timer.Reset(d)
+			return
+		}
+		var bf bytes.Buffer
+		pprof.Lookup("goroutine").WriteTo(&bf, 2)
+		// a hung task never completes, so the dump is reported right away
+		name := t.Path()
+		if name == "" {
+			name = t.Label
+		}
+		reason := fmt.Sprintf("watchdog: task %s is running longer than %v", name, d)
+		t.Errorf("watchdog: task is running longer than %v", d)
+		t.AddArtifact("goroutines", bf.Bytes())
+		fmt.Fprintf(os.Stderr, "%s\n\n%s\n", reason, bf.Bytes())
+		if t.config().WatchdogAbort {
+			t.abort(reason + ", aborted")
+		}
+	})
+	return func() {
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+		timer.Stop()
+	}
+}
+
+// abort stops profiling, restores the thread settings, writes partial
+// result with unfinished tasks and exits
+func (t *T) abort(reason string) {
+	root := t
+	for root.parent != nil {
+		root = root.parent
+	}
+	b := root.bench
+	if b == nil {
+		b = &Benchmark{T: root}
+	}
+	b.aborting.Do(func() {
+		for i := len(b.atAbort) - 1; i >= 0; i-- {
+			b.atAbort[i]()
+		}
+		if _, err := b.writeResult(b.writePartialJson); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fmt.Fprintln(os.Stderr, reason)
+		os.Exit(2)
+	})
+}
+
+// onAbort registers a function called when the watchdog aborts the run,
+// it's called before tasks are started
+func (b *Benchmark) onAbort(f func()) {
+	b.atAbort = append(b.atAbort, f)
+}
+
+func (t *T) AddArtifact(name string, data []byte) {
+	t.mu.Lock()
+	if t.Artifacts == nil {
+		t.Artifacts = make(map[string][]byte)
+	}
+	t.Artifacts[name] = data
+	t.mu.Unlock()
+}
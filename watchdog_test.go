@@ -0,0 +1,79 @@
+package benchmark
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	c := DefaultConfig()
+	c.Watchdog = 10 * time.Millisecond
+
+	var dumped bool
+	NewRunner(c).Run(".", func(t *T) error {
+		return t.Run("ItSleeps", func(t *T) error {
+			time.Sleep(50 * time.Millisecond)
+			t.mu.Lock()
+			_, dumped = t.Artifacts["goroutines"]
+			t.mu.Unlock()
+			return nil
+		})
+	})
+
+	if !dumped {
+		t.Errorf("goroutines are not dumped while the task is running")
+	}
+}
+
+func TestWatchdogAbort(t *testing.T) {
+	if result := os.Getenv("BENCHMARK_TEST_ABORT"); result != "" {
+		c := DefaultConfig()
+		c.Watchdog = 50 * time.Millisecond
+		c.WatchdogAbort = true
+		c.Pprof = true
+		c.Result = result
+		NewRunner(c).Run(".", func(t *T) error {
+			t.Run("ItFinishes", func(t *T) error {
+				t.Start()
+				return nil
+			})
+			return t.Run("ItSpins", func(t *T) error {
+				for {
+					t.Start()
+				}
+			})
+		})
+		t.Fatal("run is not aborted")
+	}
+
+	result := filepath.Join(t.TempDir(), "result.json")
+	cmd := exec.Command(os.Args[0], "-test.run=TestWatchdogAbort")
+	cmd.Env = append(os.Environ(), "BENCHMARK_TEST_ABORT="+result)
+	out, err := cmd.CombinedOutput()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 2 {
+		t.Fatalf("run is not aborted: %v\n%s", err, out)
+	}
+
+	f, err := os.Open(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	b := &Benchmark{}
+	if err := b.ReadJson(f); err != nil {
+		t.Fatal(err)
+	}
+	if b.Metadata == nil || b.Children.Len() != 2 {
+		t.Fatalf("partial result is not written")
+	}
+	c0, c1 := b.Children.Front().Value.(*T), b.Children.Back().Value.(*T)
+	if c0.Failed() || c0.Count != 1 {
+		t.Errorf("finished task is not written")
+	}
+	if _, ok := c1.Artifacts["goroutines"]; !ok || !c1.Failed() {
+		t.Errorf("aborted task is not written")
+	}
+}